@@ -0,0 +1,102 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+// AddAuditEntry appends to the audit log in the transaction of the change
+// the entry describes.
+func (b Batch) AddAuditEntry(entry models.AuditEntry) error {
+	before, err := json.Marshal(entry.Old)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	after, err := json.Marshal(entry.New)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	changes, err := json.Marshal(entry.Changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	if _, err = b.tx.Exec(`INSERT INTO audit (time, user, action, task_id, old, new, changes)
+        VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		entry.Time, entry.User, entry.Action, entry.TaskId, string(before), string(after), string(changes)); err != nil {
+		return fmt.Errorf("failed to add audit entry: %w", err)
+	}
+	return nil
+}
+
+// AuditFilter narrows the audit log; empty fields match everything. Since
+// and Until bound the entry time as RFC 3339 UTC strings, Until exclusively.
+type AuditFilter struct {
+	TaskId string
+	User   string
+	Action string
+	Since  string
+	Until  string
+	Limit  int
+}
+
+// AuditLog returns the matching entries, newest first.
+func (s Store) AuditLog(filter AuditFilter) ([]models.AuditEntry, error) {
+	var (
+		where []string
+		args  []any
+	)
+	for _, cond := range []struct {
+		sql   string
+		value string
+	}{
+		{`task_id = ?`, filter.TaskId},
+		{`user = ?`, filter.User},
+		{`action = ?`, filter.Action},
+		{`time >= ?`, filter.Since},
+		{`time < ?`, filter.Until},
+	} {
+		if cond.value != "" {
+			where = append(where, cond.sql)
+			args = append(args, cond.value)
+		}
+	}
+
+	query := `SELECT id, time, user, action, task_id, old, new, changes FROM audit`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, filter.Limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var (
+			entry                  models.AuditEntry
+			before, after, changes []byte
+		)
+		if err = rows.Scan(&entry.Id, &entry.Time, &entry.User, &entry.Action, &entry.TaskId, &before, &after, &changes); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		if err = json.Unmarshal(before, &entry.Old); err != nil {
+			return nil, fmt.Errorf("failed to decode audit entry: %w", err)
+		}
+		if err = json.Unmarshal(after, &entry.New); err != nil {
+			return nil, fmt.Errorf("failed to decode audit entry: %w", err)
+		}
+		if err = json.Unmarshal(changes, &entry.Changes); err != nil {
+			return nil, fmt.Errorf("failed to decode audit entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
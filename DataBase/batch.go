@@ -0,0 +1,45 @@
+package database
+
+import (
+	"database/sql"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+// Batch makes task changes inside a single transaction, see Store.Batch.
+// Handlers make every task change through a Batch so that its audit entry
+// and webhook deliveries are written in the same transaction.
+type Batch struct {
+	tx *sql.Tx
+}
+
+// Batch runs fn in a transaction that is committed only if fn returns nil,
+// so either every change made through b is applied or none is.
+func (s Store) Batch(fn func(b Batch) error) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		return fn(Batch{tx: tx})
+	})
+}
+
+func (b Batch) GetTask(id string) (models.Task, error) {
+	return getTask(b.tx, id)
+}
+
+func (b Batch) AddTask(task models.Task) (string, error) {
+	return addTask(b.tx, task)
+}
+
+func (b Batch) UpdateTask(task models.Task) error {
+	return updateTask(b.tx, task)
+}
+
+// DeleteTask moves the task to the trash, see trashTask.
+func (b Batch) DeleteTask(id string, version int, deletedAt string) error {
+	return trashTask(b.tx, id, version, deletedAt)
+}
+
+// CompleteTask records the completion and then either moves the task to its
+// next occurrence, resetting its checklist, or deletes it when there is none.
+func (b Batch) CompleteTask(completion models.Completion, next models.Occurrence) error {
+	return completeTask(b.tx, completion, next)
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+func (s Store) AddChecklistItem(taskID, title string) (string, error) {
+	var id string
+	err := s.Batch(func(b Batch) error {
+		var err error
+		id, err = b.AddChecklistItem(taskID, title)
+		return err
+	})
+	return id, err
+}
+
+func (b Batch) AddChecklistItem(taskID, title string) (string, error) {
+	if _, err := getTask(b.tx, taskID); err != nil {
+		return "", err
+	}
+
+	res, err := b.tx.Exec(`INSERT INTO checklist (task_id, position, title)
+        VALUES (?, (SELECT COALESCE(MAX(position), 0) + 1 FROM checklist WHERE task_id = ?), ?)`,
+		taskID, taskID, title)
+	if err != nil {
+		return "", fmt.Errorf("failed to add checklist item: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return "", fmt.Errorf("failed to get checklist item id: %w", err)
+	}
+	return strconv.FormatInt(id, 10), nil
+}
+
+func (s Store) Checklist(taskID string) ([]models.ChecklistItem, error) {
+	rows, err := s.db.Query(`SELECT id, task_id, position, title, done FROM checklist
+        WHERE task_id = ? ORDER BY position`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checklist: %w", err)
+	}
+	defer rows.Close()
+
+	items := []models.ChecklistItem{}
+	for rows.Next() {
+		var item models.ChecklistItem
+		if err = rows.Scan(&item.Id, &item.TaskId, &item.Position, &item.Title, &item.Done); err != nil {
+			return nil, fmt.Errorf("failed to scan checklist item: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// ChecklistItemTask returns the id of the task a checklist item belongs to.
+func (b Batch) ChecklistItemTask(id string) (string, error) {
+	var taskID string
+	err := b.tx.QueryRow(`SELECT task_id FROM checklist WHERE id = ?`, id).Scan(&taskID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get checklist item: %w", err)
+	}
+	return taskID, nil
+}
+
+func (s Store) SetChecklistItemDone(id string, done bool) error {
+	return s.Batch(func(b Batch) error {
+		return b.SetChecklistItemDone(id, done)
+	})
+}
+
+func (b Batch) SetChecklistItemDone(id string, done bool) error {
+	res, err := b.tx.Exec(`UPDATE checklist SET done = ? WHERE id = ?`, done, id)
+	if err != nil {
+		return fmt.Errorf("failed to update checklist item: %w", err)
+	}
+	return checkAffected(res)
+}
+
+func (b Batch) DeleteChecklistItem(id string) error {
+	res, err := b.tx.Exec(`DELETE FROM checklist WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete checklist item: %w", err)
+	}
+	return checkAffected(res)
+}
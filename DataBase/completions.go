@@ -0,0 +1,33 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+// History returns the completions of the task, newest first. It keeps working
+// after a one-off task has been done and deleted.
+func (s Store) History(taskID string) ([]models.Completion, error) {
+	rows, err := s.db.Query(`SELECT id, task_id, title, date, done_at, note FROM completions
+        WHERE task_id = ? ORDER BY done_at DESC, id DESC`, taskID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history: %w", err)
+	}
+	defer rows.Close()
+
+	return scanCompletions(rows)
+}
+
+func scanCompletions(rows *sql.Rows) ([]models.Completion, error) {
+	completions := []models.Completion{}
+	for rows.Next() {
+		var c models.Completion
+		if err := rows.Scan(&c.Id, &c.TaskId, &c.Title, &c.Date, &c.DoneAt, &c.Note); err != nil {
+			return nil, fmt.Errorf("failed to scan completion: %w", err)
+		}
+		completions = append(completions, c)
+	}
+	return completions, rows.Err()
+}
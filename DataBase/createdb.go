@@ -3,22 +3,34 @@ package database
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"log"
 	"os"
 
-	"github.com/paran0iaa/TODO/internal/services"
-
 	_ "github.com/mattn/go-sqlite3"
 )
 
-func CreateDb(dbName string) {
-	db, err := sql.Open("sqlite3", services.GetEnv("TODO_DBFILE"))
+// CreateDb opens the database file, creating it if needed, and applies the
+// pending migrations.
+func CreateDb(dbName string) *sql.DB {
+	db := OpenDb(dbName)
+	if _, err := Migrate(db); err != nil {
+		log.Fatalf("failed to migrate database: %v", err)
+	}
+	return db
+}
+
+// OpenDb opens the database file, creating the scheduler table when the file
+// is new, without migrating it.
+func OpenDb(dbName string) *sql.DB {
+	_, statErr := os.Stat(dbName)
+
+	db, err := sql.Open("sqlite3", dbName)
 	if err != nil {
 		log.Fatalf("failed to open database: %v", err)
 	}
-	defer db.Close()
 
-	if _, err := os.Stat(services.GetEnv("TODO_DBFILE")); os.IsNotExist(err) {
+	if os.IsNotExist(statErr) {
 		_, err = db.ExecContext(context.Background(),
 			`CREATE TABLE IF NOT EXISTS scheduler (
                 id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -38,4 +50,199 @@ func CreateDb(dbName string) {
 			log.Fatalf("failed to create index: %v", err)
 		}
 	}
+
+	return db
+}
+
+// Migrate applies the migrations the database hasn't had yet and returns how
+// many it applied. The number applied so far is kept in user_version.
+func Migrate(db *sql.DB) (int, error) {
+	var version int
+	if err := db.QueryRow(`PRAGMA user_version`).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+	if version > len(migrations) {
+		return 0, fmt.Errorf("schema version %d is newer than this binary's %d", version, len(migrations))
+	}
+
+	pending := migrations[version:]
+	if len(pending) > 0 {
+		tx, err := db.Begin()
+		if err != nil {
+			return 0, fmt.Errorf("failed to begin transaction: %w", err)
+		}
+		defer tx.Rollback()
+
+		for i, stmt := range pending {
+			if _, err = tx.Exec(stmt); err != nil {
+				return 0, fmt.Errorf("failed to apply migration %d: %w", version+i+1, err)
+			}
+		}
+		if _, err = tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, len(migrations))); err != nil {
+			return 0, fmt.Errorf("failed to set schema version: %w", err)
+		}
+		if err = tx.Commit(); err != nil {
+			return 0, err
+		}
+	}
+
+	// The search index depends on the build tags rather than the version.
+	if err := migrateSearch(db); err != nil {
+		return 0, fmt.Errorf("failed to migrate search index: %w", err)
+	}
+	return len(pending), nil
+}
+
+// migrations are applied in order, each once. Databases from before
+// user_version was kept run all of them again, so each statement must be
+// idempotent, and new ones are only ever appended.
+var migrations = []string{
+	`CREATE TABLE IF NOT EXISTS checklist (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        task_id INTEGER NOT NULL,
+        position INTEGER NOT NULL,
+        title TEXT NOT NULL,
+        done INTEGER NOT NULL DEFAULT 0
+    );`,
+	`CREATE INDEX IF NOT EXISTS checklist_task ON checklist (task_id, position);`,
+	`CREATE TABLE IF NOT EXISTS dependencies (
+        task_id INTEGER NOT NULL,
+        depends_on INTEGER NOT NULL,
+        PRIMARY KEY (task_id, depends_on)
+    );`,
+	`CREATE INDEX IF NOT EXISTS dependencies_depends_on ON dependencies (depends_on);`,
+	`CREATE TABLE IF NOT EXISTS completions (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        task_id INTEGER NOT NULL,
+        title TEXT NOT NULL,
+        date TEXT NOT NULL,
+        done_at TEXT NOT NULL,
+        note TEXT NOT NULL DEFAULT ''
+    );`,
+	`CREATE INDEX IF NOT EXISTS completions_task ON completions (task_id, done_at);`,
+	`CREATE TABLE IF NOT EXISTS reminders (
+        task_id INTEGER PRIMARY KEY,
+        days_before INTEGER NOT NULL,
+        at TEXT NOT NULL,
+        channel TEXT NOT NULL,
+        target TEXT NOT NULL,
+        last_sent TEXT NOT NULL DEFAULT '',
+        attempts INTEGER NOT NULL DEFAULT 0,
+        next_attempt TEXT NOT NULL DEFAULT ''
+    );`,
+	`CREATE TABLE IF NOT EXISTS webhooks (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        url TEXT NOT NULL,
+        secret TEXT NOT NULL,
+        events TEXT NOT NULL DEFAULT ''
+    );`,
+	`CREATE TABLE IF NOT EXISTS webhook_deliveries (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        webhook_id INTEGER NOT NULL,
+        event TEXT NOT NULL,
+        payload TEXT NOT NULL,
+        status TEXT NOT NULL DEFAULT 'pending',
+        attempts INTEGER NOT NULL DEFAULT 0,
+        next_attempt TEXT NOT NULL,
+        response_code INTEGER NOT NULL DEFAULT 0,
+        last_error TEXT NOT NULL DEFAULT '',
+        created_at TEXT NOT NULL,
+        delivered_at TEXT NOT NULL DEFAULT ''
+    );`,
+	`CREATE INDEX IF NOT EXISTS webhook_deliveries_pending ON webhook_deliveries (status, next_attempt);`,
+	`CREATE TABLE IF NOT EXISTS task_tags (
+        task_id INTEGER NOT NULL,
+        tag TEXT NOT NULL,
+        PRIMARY KEY (task_id, tag)
+    );`,
+	`CREATE INDEX IF NOT EXISTS task_tags_tag ON task_tags (tag);`,
+	`CREATE TABLE IF NOT EXISTS users (
+        name TEXT PRIMARY KEY,
+        password_hash TEXT NOT NULL
+    );`,
+	`CREATE TABLE IF NOT EXISTS saved_filters (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        user TEXT NOT NULL,
+        name TEXT NOT NULL,
+        query TEXT NOT NULL,
+        UNIQUE (user, name)
+    );`,
+	// task_versions holds the version of tasks changed since they were
+	// created; a task without a row is at version 1.
+	`CREATE TABLE IF NOT EXISTS task_versions (
+        task_id INTEGER PRIMARY KEY,
+        version INTEGER NOT NULL
+    );`,
+	`CREATE TRIGGER IF NOT EXISTS scheduler_version_update AFTER UPDATE ON scheduler BEGIN
+        INSERT INTO task_versions (task_id, version) VALUES (new.id, 2)
+            ON CONFLICT (task_id) DO UPDATE SET version = version + 1;
+    END;`,
+	// trash keeps deleted tasks until they are restored or purged. Their
+	// checklist, dependencies, reminder and tags stay in place meanwhile.
+	`CREATE TABLE IF NOT EXISTS trash (
+        id INTEGER PRIMARY KEY,
+        date TEXT NOT NULL,
+        title TEXT NOT NULL,
+        comment TEXT NOT NULL,
+        repeat TEXT NOT NULL,
+        version INTEGER NOT NULL,
+        deleted_at TEXT NOT NULL
+    );`,
+	`CREATE INDEX IF NOT EXISTS trash_deleted_at ON trash (deleted_at);`,
+	`CREATE TABLE IF NOT EXISTS audit (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        time TEXT NOT NULL,
+        user TEXT NOT NULL,
+        action TEXT NOT NULL,
+        task_id TEXT NOT NULL,
+        old TEXT NOT NULL,
+        new TEXT NOT NULL,
+        changes TEXT NOT NULL
+    );`,
+	`CREATE INDEX IF NOT EXISTS audit_task ON audit (task_id, id);`,
+	`CREATE TRIGGER IF NOT EXISTS audit_no_update BEFORE UPDATE ON audit BEGIN
+        SELECT RAISE(ABORT, 'audit log is append-only');
+    END;`,
+	`CREATE TRIGGER IF NOT EXISTS audit_no_delete BEFORE DELETE ON audit BEGIN
+        SELECT RAISE(ABORT, 'audit log is append-only');
+    END;`,
+	`CREATE TRIGGER IF NOT EXISTS scheduler_version_delete AFTER DELETE ON scheduler BEGIN
+        DELETE FROM task_versions WHERE task_id = old.id;
+    END;`,
+	// tags are stored comma-separated like in the tasks listing, checklist as
+	// a JSON array of item titles.
+	`CREATE TABLE IF NOT EXISTS templates (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        name TEXT NOT NULL UNIQUE,
+        title TEXT NOT NULL,
+        comment TEXT NOT NULL,
+        repeat TEXT NOT NULL,
+        tags TEXT NOT NULL,
+        checklist TEXT NOT NULL
+    );`,
+	// snoozed keeps the scheduled date of a recurring task whose current
+	// occurrence was postponed, so that the series continues from it.
+	`CREATE TABLE IF NOT EXISTS snoozed (
+        task_id INTEGER PRIMARY KEY,
+        anchor TEXT NOT NULL
+    );`,
+	`CREATE TABLE IF NOT EXISTS repeat_modes (
+        task_id INTEGER PRIMARY KEY,
+        mode TEXT NOT NULL
+    );`,
+	// exceptions skip the occurrence of a recurring task scheduled on date,
+	// or move it when moved_to is set.
+	`CREATE TABLE IF NOT EXISTS exceptions (
+        task_id INTEGER NOT NULL,
+        date TEXT NOT NULL,
+        moved_to TEXT NOT NULL DEFAULT '',
+        PRIMARY KEY (task_id, date)
+    );`,
+	// task_times holds the time of day and the estimate in minutes of the
+	// tasks that have them.
+	`CREATE TABLE IF NOT EXISTS task_times (
+        task_id INTEGER PRIMARY KEY,
+        time TEXT NOT NULL DEFAULT '',
+        estimate INTEGER NOT NULL DEFAULT 0
+    );`,
 }
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Task listing orders. Every order ends with id so that it is total, which
+// the keyset cursor relies on. A text search without an explicit sort is
+// ordered by relevance first.
+const (
+	SortDate      = "date"
+	SortDateDesc  = "-date"
+	SortTitle     = "title"
+	SortTitleDesc = "-title"
+	SortCreated   = "created"
+	SortNewest    = "-created"
+
+	sortRelevance = "relevance"
+)
+
+type sortKey struct {
+	column string
+	desc   bool
+}
+
+var taskSorts = map[string][]sortKey{
+	SortDate:      {{"date", false}, {"id", false}},
+	SortDateDesc:  {{"date", true}, {"id", true}},
+	SortTitle:     {{"title", false}, {"id", false}},
+	SortTitleDesc: {{"title", true}, {"id", true}},
+	SortCreated:   {{"id", false}},
+	SortNewest:    {{"id", true}},
+	sortRelevance: {{"rank", false}, {"date", false}, {"id", false}},
+}
+
+func ValidSort(sort string) bool {
+	_, ok := taskSorts[sort]
+	return ok && sort != sortRelevance
+}
+
+func orderBy(keys []sortKey) string {
+	terms := make([]string, len(keys))
+	for i, key := range keys {
+		terms[i] = key.column
+		if key.desc {
+			terms[i] += ` DESC`
+		}
+	}
+	return strings.Join(terms, `, `)
+}
+
+// cursor is the position after the last task of a page: the sort it was
+// issued for and that task's values of the sort keys.
+type cursor struct {
+	Sort string   `json:"s"`
+	Keys []string `json:"k"`
+}
+
+func encodeCursor(sort string, keys []sortKey, task models.Task, rank float64) string {
+	c := cursor{Sort: sort}
+	for _, key := range keys {
+		switch key.column {
+		case "rank":
+			c.Keys = append(c.Keys, strconv.FormatFloat(rank, 'g', -1, 64))
+		case "date":
+			c.Keys = append(c.Keys, task.Date)
+		case "title":
+			c.Keys = append(c.Keys, task.Title)
+		case "id":
+			c.Keys = append(c.Keys, task.Id)
+		}
+	}
+
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// afterCursor returns the condition selecting the tasks that follow the
+// cursor in the given order.
+func afterCursor(token, sort string, keys []sortKey) (string, []any, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return "", nil, ErrInvalidCursor
+	}
+	var c cursor
+	if err = json.Unmarshal(data, &c); err != nil || c.Sort != sort || len(c.Keys) != len(keys) {
+		return "", nil, ErrInvalidCursor
+	}
+
+	values := make([]any, len(keys))
+	for i, key := range keys {
+		switch key.column {
+		case "rank":
+			values[i], err = strconv.ParseFloat(c.Keys[i], 64)
+		case "id":
+			values[i], err = strconv.ParseInt(c.Keys[i], 10, 64)
+		default:
+			values[i] = c.Keys[i]
+		}
+		if err != nil {
+			return "", nil, ErrInvalidCursor
+		}
+	}
+
+	// (k1, k2, k3) after (v1, v2, v3) expands to
+	// k1 > v1 OR (k1 = v1 AND (k2 > v2 OR (k2 = v2 AND k3 > v3))).
+	var (
+		cond string
+		args []any
+	)
+	for i := len(keys) - 1; i >= 0; i-- {
+		op := `>`
+		if keys[i].desc {
+			op = `<`
+		}
+		next := fmt.Sprintf(`%s %s ?`, keys[i].column, op)
+		nextArgs := []any{values[i]}
+		if cond != "" {
+			next = fmt.Sprintf(`(%s OR (%s = ? AND %s))`, next, keys[i].column, cond)
+			nextArgs = append(nextArgs, values[i])
+			nextArgs = append(nextArgs, args...)
+		}
+		cond, args = next, nextArgs
+	}
+	return cond, args, nil
+}
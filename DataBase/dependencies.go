@@ -0,0 +1,63 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+var ErrDependencyCycle = errors.New("dependency would create a cycle")
+
+func (s Store) AddDependency(taskID, dependsOn string) error {
+	return s.Batch(func(b Batch) error {
+		return b.AddDependency(taskID, dependsOn)
+	})
+}
+
+func (b Batch) AddDependency(taskID, dependsOn string) error {
+	if taskID == dependsOn {
+		return ErrDependencyCycle
+	}
+	for _, id := range []string{taskID, dependsOn} {
+		if _, err := getTask(b.tx, id); err != nil {
+			return err
+		}
+	}
+
+	// The new edge closes a cycle if taskID is already reachable from dependsOn.
+	var cycle bool
+	err := b.tx.QueryRow(`WITH RECURSIVE reachable(id) AS (
+            SELECT CAST(? AS INTEGER)
+            UNION
+            SELECT d.depends_on FROM dependencies d JOIN reachable r ON d.task_id = r.id
+        )
+        SELECT EXISTS (SELECT 1 FROM reachable WHERE id = CAST(? AS INTEGER))`, dependsOn, taskID).Scan(&cycle)
+	if err != nil {
+		return fmt.Errorf("failed to check dependency cycle: %w", err)
+	}
+	if cycle {
+		return ErrDependencyCycle
+	}
+
+	if _, err = b.tx.Exec(`INSERT OR IGNORE INTO dependencies (task_id, depends_on) VALUES (?, ?)`,
+		taskID, dependsOn); err != nil {
+		return fmt.Errorf("failed to add dependency: %w", err)
+	}
+	return nil
+}
+
+func (b Batch) DeleteDependency(taskID, dependsOn string) error {
+	res, err := b.tx.Exec(`DELETE FROM dependencies WHERE task_id = ? AND depends_on = ?`, taskID, dependsOn)
+	if err != nil {
+		return fmt.Errorf("failed to delete dependency: %w", err)
+	}
+	return checkAffected(res)
+}
+
+// Dependencies returns the prerequisites of the task.
+func (s Store) Dependencies(taskID string) ([]models.Task, error) {
+	return s.queryTasks(`SELECT `+taskColumns+` FROM scheduler s
+        JOIN dependencies dep ON dep.depends_on = s.id
+        WHERE dep.task_id = ? ORDER BY s.date, s.id`, taskID)
+}
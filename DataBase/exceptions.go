@@ -0,0 +1,30 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+// SaveException adds or replaces the exception for an occurrence of the
+// task. When the exception changes the current occurrence, next becomes the
+// current one.
+func (b Batch) SaveException(taskID string, e models.Exception, next *models.Occurrence) error {
+	if _, err := b.tx.Exec(`INSERT INTO exceptions (task_id, date, moved_to) VALUES (?, ?, ?)
+        ON CONFLICT (task_id, date) DO UPDATE SET moved_to = excluded.moved_to`,
+		taskID, e.Date, e.MovedTo); err != nil {
+		return fmt.Errorf("failed to save exception: %w", err)
+	}
+	if next == nil {
+		return nil
+	}
+	return setOccurrence(b.tx, taskID, *next)
+}
+
+func (b Batch) DeleteException(taskID, date string) error {
+	res, err := b.tx.Exec(`DELETE FROM exceptions WHERE task_id = ? AND date = ?`, taskID, date)
+	if err != nil {
+		return fmt.Errorf("failed to delete exception: %w", err)
+	}
+	return checkAffected(res)
+}
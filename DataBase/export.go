@@ -0,0 +1,84 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+// ExportTasks returns every task with its checklist, prerequisites, reminder
+// and completion history.
+func (s Store) ExportTasks() ([]models.TaskExport, error) {
+	tasks, err := s.AllTasks()
+	if err != nil {
+		return nil, err
+	}
+
+	exported := make([]models.TaskExport, 0, len(tasks))
+	for _, task := range tasks {
+		e := models.TaskExport{Task: task}
+		if e.Checklist, err = s.Checklist(task.Id); err != nil {
+			return nil, err
+		}
+		deps, err := s.Dependencies(task.Id)
+		if err != nil {
+			return nil, err
+		}
+		for _, dep := range deps {
+			e.Dependencies = append(e.Dependencies, dep.Id)
+		}
+		reminder, err := s.GetReminder(task.Id)
+		if err == nil {
+			e.Reminder = &reminder
+		} else if !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+		if e.History, err = s.History(task.Id); err != nil {
+			return nil, err
+		}
+		exported = append(exported, e)
+	}
+	return exported, nil
+}
+
+// ImportTask adds an exported task as a new one, with everything attached
+// to it but its dependencies, which need the ids of the other new tasks.
+func (b Batch) ImportTask(e models.TaskExport) (string, error) {
+	id, err := addTask(b.tx, e.Task)
+	if err != nil {
+		return "", err
+	}
+	if e.Anchor != "" {
+		if _, err = b.tx.Exec(`INSERT INTO snoozed (task_id, anchor) VALUES (?, ?)`, id, e.Anchor); err != nil {
+			return "", fmt.Errorf("failed to save snooze: %w", err)
+		}
+	}
+	for _, ex := range e.Exceptions {
+		if _, err = b.tx.Exec(`INSERT INTO exceptions (task_id, date, moved_to) VALUES (?, ?, ?)`,
+			id, ex.Date, ex.MovedTo); err != nil {
+			return "", fmt.Errorf("failed to save exception: %w", err)
+		}
+	}
+	for i, item := range e.Checklist {
+		if _, err = b.tx.Exec(`INSERT INTO checklist (task_id, position, title, done) VALUES (?, ?, ?, ?)`,
+			id, i+1, item.Title, item.Done); err != nil {
+			return "", fmt.Errorf("failed to add checklist item: %w", err)
+		}
+	}
+	if r := e.Reminder; r != nil {
+		if _, err = b.tx.Exec(`INSERT INTO reminders (task_id, days_before, at, channel, target, last_sent)
+            VALUES (?, ?, ?, ?, ?, ?)`, id, r.DaysBefore, r.At, r.Channel, r.Target, r.LastSent); err != nil {
+			return "", fmt.Errorf("failed to set reminder: %w", err)
+		}
+	}
+	// History is newest first, like Store.History.
+	for i := len(e.History) - 1; i >= 0; i-- {
+		c := e.History[i]
+		if _, err = b.tx.Exec(`INSERT INTO completions (task_id, title, date, done_at, note) VALUES (?, ?, ?, ?, ?)`,
+			id, c.Title, c.Date, c.DoneAt, c.Note); err != nil {
+			return "", fmt.Errorf("failed to record completion: %w", err)
+		}
+	}
+	return id, nil
+}
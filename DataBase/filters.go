@@ -0,0 +1,62 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+// SaveFilter creates the user's filter or replaces the query of the one with
+// the same name.
+func (s Store) SaveFilter(user string, f models.SavedFilter) (string, error) {
+	var id int64
+	err := s.db.QueryRow(`INSERT INTO saved_filters (user, name, query) VALUES (?, ?, ?)
+        ON CONFLICT (user, name) DO UPDATE SET query = excluded.query
+        RETURNING id`, user, f.Name, f.Query).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("failed to save filter: %w", err)
+	}
+	return strconv.FormatInt(id, 10), nil
+}
+
+func (s Store) Filters(user string) ([]models.SavedFilter, error) {
+	rows, err := s.db.Query(`SELECT id, name, query FROM saved_filters WHERE user = ? ORDER BY name`, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get filters: %w", err)
+	}
+	defer rows.Close()
+
+	filters := []models.SavedFilter{}
+	for rows.Next() {
+		var f models.SavedFilter
+		if err = rows.Scan(&f.Id, &f.Name, &f.Query); err != nil {
+			return nil, fmt.Errorf("failed to scan filter: %w", err)
+		}
+		filters = append(filters, f)
+	}
+	return filters, rows.Err()
+}
+
+func (s Store) GetFilter(user, name string) (models.SavedFilter, error) {
+	var f models.SavedFilter
+	err := s.db.QueryRow(`SELECT id, name, query FROM saved_filters WHERE user = ? AND name = ?`, user, name).
+		Scan(&f.Id, &f.Name, &f.Query)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.SavedFilter{}, ErrNotFound
+	}
+	if err != nil {
+		return models.SavedFilter{}, fmt.Errorf("failed to get filter: %w", err)
+	}
+	return f, nil
+}
+
+func (s Store) DeleteFilter(user, id string) error {
+	res, err := s.db.Exec(`DELETE FROM saved_filters WHERE user = ? AND id = ?`, user, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete filter: %w", err)
+	}
+	return checkAffected(res)
+}
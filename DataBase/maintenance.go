@@ -0,0 +1,16 @@
+package database
+
+import "fmt"
+
+// Vacuum rebuilds the database file, reclaiming the space of deleted rows.
+func (s Store) Vacuum() error {
+	if _, err := s.db.Exec(`VACUUM`); err != nil {
+		return fmt.Errorf("failed to vacuum database: %w", err)
+	}
+	return nil
+}
+
+// Migrate applies the pending migrations, see Migrate.
+func (s Store) Migrate() (int, error) {
+	return Migrate(s.db)
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/paran0iaa/TODO/internal/query"
+)
+
+// compileQuery turns a parsed query into a WHERE condition over the columns
+// of the Tasks source. Every value is passed as a parameter.
+func compileQuery(node query.Node) (string, []any, error) {
+	switch n := node.(type) {
+	case query.And:
+		return compileBinary(n.Left, n.Right, "AND")
+	case query.Or:
+		return compileBinary(n.Left, n.Right, "OR")
+	case query.Not:
+		cond, args, err := compileQuery(n.X)
+		if err != nil {
+			return "", nil, err
+		}
+		return `NOT (` + cond + `)`, args, nil
+	case query.Term:
+		return compileTerm(n)
+	default:
+		return "", nil, fmt.Errorf("unexpected query node %T", node)
+	}
+}
+
+func compileBinary(left, right query.Node, op string) (string, []any, error) {
+	lcond, largs, err := compileQuery(left)
+	if err != nil {
+		return "", nil, err
+	}
+	rcond, rargs, err := compileQuery(right)
+	if err != nil {
+		return "", nil, err
+	}
+	return `(` + lcond + ` ` + op + ` ` + rcond + `)`, append(largs, rargs...), nil
+}
+
+var dateOps = map[string]string{":": "=", "<": "<", "<=": "<=", ">": ">", ">=": ">="}
+
+func compileTerm(t query.Term) (string, []any, error) {
+	switch t.Field {
+	case query.FieldText:
+		pattern := likeContains(t.Value)
+		return `(title LIKE ? ESCAPE '\' OR comment LIKE ? ESCAPE '\')`, []any{pattern, pattern}, nil
+	case query.FieldTitle, query.FieldComment:
+		return t.Field + ` LIKE ? ESCAPE '\'`, []any{likeContains(t.Value)}, nil
+	case query.FieldRepeat:
+		return `repeat LIKE ? ESCAPE '\'`, []any{likeGlob(t.Value)}, nil
+	case query.FieldTag:
+		return `id IN (SELECT task_id FROM task_tags WHERE tag LIKE ? ESCAPE '\')`, []any{likeGlob(t.Value)}, nil
+	case query.FieldDue:
+		return `date ` + dateOps[t.Op] + ` ?`, []any{t.Value}, nil
+	case query.FieldIs:
+		switch t.Value {
+		case "blocked":
+			return `blocked`, nil, nil
+		case "ready":
+			return `NOT blocked`, nil, nil
+		}
+	}
+	return "", nil, fmt.Errorf("unsupported term %s%s%s", t.Field, t.Op, t.Value)
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likeGlob matches the whole value, where * stands for any text.
+func likeGlob(value string) string {
+	return strings.ReplaceAll(likeEscaper.Replace(value), "*", "%")
+}
+
+// likeContains matches the value anywhere; * is a wildcard here too.
+func likeContains(value string) string {
+	return "%" + likeGlob(value) + "%"
+}
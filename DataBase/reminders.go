@@ -0,0 +1,107 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+// SetReminder creates or replaces the reminder of a task.
+func (s Store) SetReminder(r models.Reminder) error {
+	return s.Batch(func(b Batch) error {
+		return b.SetReminder(r)
+	})
+}
+
+func (b Batch) SetReminder(r models.Reminder) error {
+	if _, err := getTask(b.tx, r.TaskId); err != nil {
+		return err
+	}
+
+	_, err := b.tx.Exec(`INSERT INTO reminders (task_id, days_before, at, channel, target) VALUES (?, ?, ?, ?, ?)
+        ON CONFLICT (task_id) DO UPDATE SET days_before = excluded.days_before, at = excluded.at,
+            channel = excluded.channel, target = excluded.target, last_sent = '', attempts = 0, next_attempt = ''`,
+		r.TaskId, r.DaysBefore, r.At, r.Channel, r.Target)
+	if err != nil {
+		return fmt.Errorf("failed to set reminder: %w", err)
+	}
+	return nil
+}
+
+func (s Store) GetReminder(taskID string) (models.Reminder, error) {
+	var r models.Reminder
+	err := s.db.QueryRow(`SELECT task_id, days_before, at, channel, target, last_sent FROM reminders
+        WHERE task_id = ?`, taskID).
+		Scan(&r.TaskId, &r.DaysBefore, &r.At, &r.Channel, &r.Target, &r.LastSent)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Reminder{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Reminder{}, fmt.Errorf("failed to get reminder: %w", err)
+	}
+	return r, nil
+}
+
+func (b Batch) DeleteReminder(taskID string) error {
+	res, err := b.tx.Exec(`DELETE FROM reminders WHERE task_id = ?`, taskID)
+	if err != nil {
+		return fmt.Errorf("failed to delete reminder: %w", err)
+	}
+	return checkAffected(res)
+}
+
+// PendingReminder is a reminder that hasn't been sent for the task's current
+// occurrence yet. Attempts counts the failed tries to send it, and
+// NextAttempt is the RFC 3339 UTC time before which it isn't tried again.
+type PendingReminder struct {
+	Reminder    models.Reminder
+	Task        models.Task
+	Attempts    int
+	NextAttempt string
+}
+
+func (s Store) PendingReminders() ([]PendingReminder, error) {
+	rows, err := s.db.Query(`SELECT r.task_id, r.days_before, r.at, r.channel, r.target, r.last_sent,
+            r.attempts, r.next_attempt, s.id, s.date, s.title, COALESCE(s.comment, ''), COALESCE(s.repeat, '')
+        FROM reminders r JOIN scheduler s ON s.id = r.task_id
+        WHERE r.last_sent <> s.date`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending reminders: %w", err)
+	}
+	defer rows.Close()
+
+	var pending []PendingReminder
+	for rows.Next() {
+		var p PendingReminder
+		err = rows.Scan(&p.Reminder.TaskId, &p.Reminder.DaysBefore, &p.Reminder.At, &p.Reminder.Channel,
+			&p.Reminder.Target, &p.Reminder.LastSent, &p.Attempts, &p.NextAttempt,
+			&p.Task.Id, &p.Task.Date, &p.Task.Title, &p.Task.Comment, &p.Task.Repeat)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reminder: %w", err)
+		}
+		pending = append(pending, p)
+	}
+	return pending, rows.Err()
+}
+
+// MarkReminderSent remembers that the reminder went out for the occurrence on
+// date, or was given up on, and clears the failed attempts.
+func (s Store) MarkReminderSent(taskID, date string) error {
+	if _, err := s.db.Exec(`UPDATE reminders SET last_sent = ?, attempts = 0, next_attempt = '' WHERE task_id = ?`,
+		date, taskID); err != nil {
+		return fmt.Errorf("failed to mark reminder sent: %w", err)
+	}
+	return nil
+}
+
+// RetryReminder records a failed attempt at sending the reminder and when to
+// try again.
+func (s Store) RetryReminder(taskID string, attempts int, nextAttempt string) error {
+	if _, err := s.db.Exec(`UPDATE reminders SET attempts = ?, next_attempt = ? WHERE task_id = ?`,
+		attempts, nextAttempt, taskID); err != nil {
+		return fmt.Errorf("failed to retry reminder: %w", err)
+	}
+	return nil
+}
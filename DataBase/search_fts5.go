@@ -0,0 +1,60 @@
+//go:build sqlite_fts5
+
+package database
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// scheduler_fts indexes titles and comments. The unicode61 tokenizer folds
+// case for Cyrillic as well as Latin text, but diacritics only for Latin, so
+// ё and е stay different letters; porter adds English stemming on top.
+var searchMigrations = []string{
+	`CREATE VIRTUAL TABLE IF NOT EXISTS scheduler_fts USING fts5(
+        title, comment,
+        content = 'scheduler', content_rowid = 'id',
+        tokenize = 'porter unicode61 remove_diacritics 2'
+    );`,
+	`CREATE TRIGGER IF NOT EXISTS scheduler_fts_insert AFTER INSERT ON scheduler BEGIN
+        INSERT INTO scheduler_fts (rowid, title, comment) VALUES (new.id, new.title, new.comment);
+    END;`,
+	`CREATE TRIGGER IF NOT EXISTS scheduler_fts_delete AFTER DELETE ON scheduler BEGIN
+        INSERT INTO scheduler_fts (scheduler_fts, rowid, title, comment) VALUES ('delete', old.id, old.title, old.comment);
+    END;`,
+	`CREATE TRIGGER IF NOT EXISTS scheduler_fts_update AFTER UPDATE ON scheduler BEGIN
+        INSERT INTO scheduler_fts (scheduler_fts, rowid, title, comment) VALUES ('delete', old.id, old.title, old.comment);
+        INSERT INTO scheduler_fts (rowid, title, comment) VALUES (new.id, new.title, new.comment);
+    END;`,
+	// A binary built without FTS5 drops the triggers, so the index may be stale.
+	`INSERT INTO scheduler_fts (scheduler_fts) VALUES ('rebuild');`,
+}
+
+func migrateSearch(db *sql.DB) error {
+	for _, stmt := range searchMigrations {
+		if _, err := db.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// searchSource returns the task source for a text search: every word is
+// matched as a prefix, results are ranked with bm25 and matches are
+// highlighted with <mark> in the snippet.
+func searchSource(search string) (string, []any) {
+	var terms []string
+	for _, word := range strings.Fields(search) {
+		word = strings.ReplaceAll(word, `"`, `""`)
+		terms = append(terms, `"`+word+`"*`)
+	}
+	if len(terms) == 0 {
+		terms = append(terms, `""`)
+	}
+
+	return `SELECT ` + taskColumns + `,
+            snippet(scheduler_fts, -1, '<mark>', '</mark>', '…', 12) AS snippet,
+            bm25(scheduler_fts) AS rank
+        FROM scheduler_fts JOIN scheduler s ON s.id = scheduler_fts.rowid
+        WHERE scheduler_fts MATCH ?`, []any{strings.Join(terms, " ")}
+}
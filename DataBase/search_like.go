@@ -0,0 +1,22 @@
+//go:build !sqlite_fts5
+
+package database
+
+import "database/sql"
+
+// Without FTS5 compiled in, search falls back to LIKE. The triggers of an
+// FTS5 build are dropped because they would make every write fail here.
+func migrateSearch(db *sql.DB) error {
+	for _, trigger := range []string{"scheduler_fts_insert", "scheduler_fts_delete", "scheduler_fts_update"} {
+		if _, err := db.Exec(`DROP TRIGGER IF EXISTS ` + trigger); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func searchSource(search string) (string, []any) {
+	pattern := "%" + likeEscaper.Replace(search) + "%"
+	return `SELECT ` + taskColumns + `, '' AS snippet, 0 AS rank FROM scheduler s
+        WHERE s.title LIKE ? ESCAPE '\' OR s.comment LIKE ? ESCAPE '\'`, []any{pattern, pattern}
+}
@@ -0,0 +1,31 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+// SnoozeTask moves task to date. A recurring task remembers its scheduled
+// date as the anchor, unless it is snoozed already, so that completing it
+// continues the series as if it had not been moved.
+func (b Batch) SnoozeTask(task models.Task, date string) error {
+	if task.Repeat != "" {
+		if _, err := b.tx.Exec(`INSERT INTO snoozed (task_id, anchor) VALUES (?, ?)
+            ON CONFLICT (task_id) DO NOTHING`, task.Id, task.Date); err != nil {
+			return fmt.Errorf("failed to save snooze: %w", err)
+		}
+	}
+
+	query := `UPDATE scheduler SET date = ? WHERE id = ?`
+	args := []any{date, task.Id}
+	if task.Version != 0 {
+		query += ` AND ` + versionIs
+		args = append(args, task.Version)
+	}
+	res, err := b.tx.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to snooze task: %w", err)
+	}
+	return checkVersion(b.tx, res, task.Id, task.Version)
+}
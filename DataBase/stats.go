@@ -0,0 +1,27 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+// AllTasks returns every task without the listing limit.
+func (s Store) AllTasks() ([]models.Task, error) {
+	return s.Tasks(TaskFilter{Limit: -1})
+}
+
+// Completions returns the completions done up to the end of the day to,
+// oldest first. done_at keeps the offset it was recorded with, so the bound
+// is widened by a day and the caller narrows it down in its own time zone.
+func (s Store) Completions(to time.Time) ([]models.Completion, error) {
+	rows, err := s.db.Query(`SELECT id, task_id, title, date, done_at, note FROM completions
+        WHERE done_at < ? ORDER BY done_at, id`, to.AddDate(0, 0, 2).Format(time.DateOnly))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get completions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanCompletions(rows)
+}
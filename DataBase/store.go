@@ -0,0 +1,472 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paran0iaa/TODO/internal/models"
+	"github.com/paran0iaa/TODO/internal/query"
+)
+
+var (
+	ErrNotFound = errors.New("not found")
+	// ErrConflict means the task is no longer at the version a change was
+	// based on.
+	ErrConflict = errors.New("version conflict")
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+func NewStore(db *sql.DB) Store {
+	return Store{db: db}
+}
+
+func addTask(tx *sql.Tx, task models.Task) (string, error) {
+	res, err := tx.Exec(`INSERT INTO scheduler (date, title, comment, repeat) VALUES (?, ?, ?, ?)`,
+		task.Date, task.Title, task.Comment, task.Repeat)
+	if err != nil {
+		return "", fmt.Errorf("failed to add task: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return "", fmt.Errorf("failed to get task id: %w", err)
+	}
+	if err = setTags(tx, id, task.Tags); err != nil {
+		return "", err
+	}
+	if err = setRepeatMode(tx, id, task.RepeatFrom); err != nil {
+		return "", err
+	}
+	if err = setTime(tx, id, task.Time, task.Estimate); err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(id, 10), nil
+}
+
+// setRepeatMode stores where the task's repeats are counted from. Only the
+// non-default mode has a row.
+func setRepeatMode(tx *sql.Tx, taskID any, mode string) error {
+	if _, err := tx.Exec(`DELETE FROM repeat_modes WHERE task_id = ?`, taskID); err != nil {
+		return fmt.Errorf("failed to clear repeat mode: %w", err)
+	}
+	if mode == "" {
+		return nil
+	}
+	if _, err := tx.Exec(`INSERT INTO repeat_modes (task_id, mode) VALUES (?, ?)`, taskID, mode); err != nil {
+		return fmt.Errorf("failed to set repeat mode: %w", err)
+	}
+	return nil
+}
+
+// setTime stores the time of day and the estimate of the task, if it has
+// either.
+func setTime(tx *sql.Tx, taskID any, time string, estimate int) error {
+	if _, err := tx.Exec(`DELETE FROM task_times WHERE task_id = ?`, taskID); err != nil {
+		return fmt.Errorf("failed to clear task time: %w", err)
+	}
+	if time == "" && estimate == 0 {
+		return nil
+	}
+	if _, err := tx.Exec(`INSERT INTO task_times (task_id, time, estimate) VALUES (?, ?, ?)`,
+		taskID, time, estimate); err != nil {
+		return fmt.Errorf("failed to set task time: %w", err)
+	}
+	return nil
+}
+
+func setTags(tx *sql.Tx, taskID any, tags []string) error {
+	if _, err := tx.Exec(`DELETE FROM task_tags WHERE task_id = ?`, taskID); err != nil {
+		return fmt.Errorf("failed to clear tags: %w", err)
+	}
+	for _, tag := range tags {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO task_tags (task_id, tag) VALUES (?, ?)`, taskID, tag); err != nil {
+			return fmt.Errorf("failed to add tag: %w", err)
+		}
+	}
+	return nil
+}
+
+// taskColumns selects a task from scheduler aliased as s. A task is blocked
+// while any one-off prerequisite is still open or a recurring prerequisite
+// has an occurrence due no later than the task itself.
+const taskColumns = `s.id, s.date, s.title, COALESCE(s.comment, '') AS comment, COALESCE(s.repeat, '') AS repeat,
+    EXISTS (SELECT 1 FROM dependencies d JOIN scheduler p ON p.id = d.depends_on
+        WHERE d.task_id = s.id AND (COALESCE(p.repeat, '') = '' OR p.date <= s.date)) AS blocked,
+    COALESCE((SELECT group_concat(tag, ',') FROM task_tags WHERE task_id = s.id), '') AS tags,
+    COALESCE((SELECT anchor FROM snoozed WHERE task_id = s.id), '') AS anchor,
+    COALESCE((SELECT mode FROM repeat_modes WHERE task_id = s.id), '') AS repeat_from,
+    COALESCE((SELECT group_concat(date || '>' || moved_to, ',') FROM exceptions WHERE task_id = s.id), '') AS exceptions,
+    COALESCE((SELECT time FROM task_times WHERE task_id = s.id), '') AS time,
+    COALESCE((SELECT estimate FROM task_times WHERE task_id = s.id), 0) AS estimate`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTask scans taskColumns followed by any extra columns.
+func scanTask(row scanner, extra ...any) (models.Task, error) {
+	var (
+		task             models.Task
+		tags, exceptions string
+	)
+	dest := append([]any{&task.Id, &task.Date, &task.Title, &task.Comment, &task.Repeat, &task.Blocked, &tags,
+		&task.Anchor, &task.RepeatFrom, &exceptions, &task.Time, &task.Estimate}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return models.Task{}, err
+	}
+	if tags != "" {
+		task.Tags = strings.Split(tags, ",")
+		sort.Strings(task.Tags)
+	}
+	if exceptions != "" {
+		for _, e := range strings.Split(exceptions, ",") {
+			date, movedTo, _ := strings.Cut(e, ">")
+			task.Exceptions = append(task.Exceptions, models.Exception{Date: date, MovedTo: movedTo})
+		}
+		sort.Slice(task.Exceptions, func(i, j int) bool {
+			return task.Exceptions[i].Date < task.Exceptions[j].Date
+		})
+	}
+	return task, nil
+}
+
+func (s Store) GetTask(id string) (models.Task, error) {
+	return getTask(s.db, id)
+}
+
+type rowQuerier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// getTask returns the task with its version, which only matters when a single
+// task is read for a change, so listings leave it out.
+func getTask(q rowQuerier, id string) (models.Task, error) {
+	var version int
+	task, err := scanTask(q.QueryRow(`SELECT `+taskColumns+`,
+            COALESCE((SELECT version FROM task_versions WHERE task_id = s.id), 1)
+        FROM scheduler s WHERE s.id = ?`, id), &version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Task{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Task{}, fmt.Errorf("failed to get task: %w", err)
+	}
+	task.Version = version
+	return task, nil
+}
+
+type TaskFilter struct {
+	// Ready keeps only tasks that are not blocked by open prerequisites.
+	Ready bool
+	// DateFrom and DateTo bound the task date inclusively when set.
+	DateFrom string
+	DateTo   string
+	// OneOff keeps only tasks without a repeat rule.
+	OneOff bool
+	// Search is either a 02.01.2006 date or text to look for in titles and
+	// comments. Text matches are ordered by relevance unless Sort is set.
+	Search string
+	// Query is a parsed /api/tasks?q= filter.
+	Query query.Node
+	// Sort is one of the Sort constants, SortDate by default.
+	Sort string
+	// Cursor continues a listing after the page that returned it.
+	Cursor string
+	// Limit caps the page size; a negative limit returns every task.
+	Limit int
+}
+
+func (s Store) Tasks(filter TaskFilter) ([]models.Task, error) {
+	tasks, _, err := s.TaskPage(filter)
+	return tasks, err
+}
+
+// TaskPage returns a page of tasks and the cursor of the next page, which is
+// empty on the last one.
+func (s Store) TaskPage(filter TaskFilter) ([]models.Task, string, error) {
+	var (
+		where []string
+		args  []any
+	)
+
+	sort := filter.Sort
+	source := `SELECT ` + taskColumns + `, '' AS snippet, 0 AS rank FROM scheduler s`
+	if filter.Search != "" {
+		if date, err := time.Parse("02.01.2006", filter.Search); err == nil {
+			where = append(where, `date = ?`)
+			args = append(args, date.Format(models.Layout))
+		} else {
+			var searchArgs []any
+			source, searchArgs = searchSource(filter.Search)
+			args = append(searchArgs, args...)
+			if sort == "" {
+				sort = sortRelevance
+			}
+		}
+	}
+	if sort == "" {
+		sort = SortDate
+	}
+	keys, ok := taskSorts[sort]
+	if !ok {
+		return nil, "", fmt.Errorf("unknown sort: %s", sort)
+	}
+
+	if filter.Ready {
+		where = append(where, `NOT blocked`)
+	}
+	if filter.DateFrom != "" {
+		where = append(where, `date >= ?`)
+		args = append(args, filter.DateFrom)
+	}
+	if filter.DateTo != "" {
+		where = append(where, `date <= ?`)
+		args = append(args, filter.DateTo)
+	}
+	if filter.OneOff {
+		where = append(where, `repeat = ''`)
+	}
+	if filter.Query != nil {
+		cond, queryArgs, err := compileQuery(filter.Query)
+		if err != nil {
+			return nil, "", err
+		}
+		where = append(where, cond)
+		args = append(args, queryArgs...)
+	}
+	if filter.Cursor != "" {
+		cond, cursorArgs, err := afterCursor(filter.Cursor, sort, keys)
+		if err != nil {
+			return nil, "", err
+		}
+		where = append(where, cond)
+		args = append(args, cursorArgs...)
+	}
+
+	// One task more than the limit tells whether there is a next page.
+	limit := filter.Limit
+	if limit > 0 {
+		limit++
+	}
+
+	query := `SELECT id, date, title, comment, repeat, blocked, tags, anchor, repeat_from, exceptions,
+        time, estimate, snippet, rank FROM (` + source + `)`
+	if len(where) > 0 {
+		query += ` WHERE ` + strings.Join(where, ` AND `)
+	}
+	query += ` ORDER BY ` + orderBy(keys) + ` LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get tasks: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		tasks = []models.Task{}
+		ranks []float64
+	)
+	for rows.Next() {
+		var (
+			snippet string
+			rank    float64
+		)
+		task, err := scanTask(rows, &snippet, &rank)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to scan task: %w", err)
+		}
+		task.Snippet = snippet
+		tasks = append(tasks, task)
+		ranks = append(ranks, rank)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, "", fmt.Errorf("failed to get tasks: %w", err)
+	}
+
+	if filter.Limit <= 0 || len(tasks) <= filter.Limit {
+		return tasks, "", nil
+	}
+	last := filter.Limit - 1
+	return tasks[:filter.Limit], encodeCursor(sort, keys, tasks[last], ranks[last]), nil
+}
+
+func (s Store) queryTasks(query string, args ...any) ([]models.Task, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tasks: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := []models.Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan task: %w", err)
+		}
+		tasks = append(tasks, task)
+	}
+	return tasks, rows.Err()
+}
+
+// updateTask overwrites the task, which must still be at task.Version unless
+// that is 0.
+func updateTask(tx *sql.Tx, task models.Task) error {
+	// Setting the date by hand makes it the anchor of the series again.
+	if _, err := tx.Exec(`DELETE FROM snoozed WHERE task_id = ?
+        AND EXISTS (SELECT 1 FROM scheduler WHERE id = ? AND date != ?)`, task.Id, task.Id, task.Date); err != nil {
+		return fmt.Errorf("failed to reset snooze: %w", err)
+	}
+
+	query := `UPDATE scheduler SET date = ?, title = ?, comment = ?, repeat = ? WHERE id = ?`
+	args := []any{task.Date, task.Title, task.Comment, task.Repeat, task.Id}
+	if task.Version != 0 {
+		query += ` AND ` + versionIs
+		args = append(args, task.Version)
+	}
+
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+	if err = checkVersion(tx, res, task.Id, task.Version); err != nil {
+		return err
+	}
+	if err = setRepeatMode(tx, task.Id, task.RepeatFrom); err != nil {
+		return err
+	}
+	if err = setTime(tx, task.Id, task.Time, task.Estimate); err != nil {
+		return err
+	}
+	return setTags(tx, task.Id, task.Tags)
+}
+
+func completeTask(tx *sql.Tx, completion models.Completion, next models.Occurrence) error {
+	if _, err := tx.Exec(`INSERT INTO completions (task_id, title, date, done_at, note) VALUES (?, ?, ?, ?, ?)`,
+		completion.TaskId, completion.Title, completion.Date, completion.DoneAt, completion.Note); err != nil {
+		return fmt.Errorf("failed to record completion: %w", err)
+	}
+
+	if next.Date == "" {
+		return deleteTask(tx, completion.TaskId)
+	}
+
+	if err := setOccurrence(tx, completion.TaskId, next); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE checklist SET done = 0 WHERE task_id = ?`, completion.TaskId); err != nil {
+		return fmt.Errorf("failed to reset checklist: %w", err)
+	}
+	return nil
+}
+
+// setOccurrence makes next the current occurrence of a recurring task. A
+// moved occurrence keeps its scheduled date as the anchor.
+func setOccurrence(tx *sql.Tx, id string, next models.Occurrence) error {
+	res, err := tx.Exec(`UPDATE scheduler SET date = ? WHERE id = ?`, next.Date, id)
+	if err != nil {
+		return fmt.Errorf("failed to update task date: %w", err)
+	}
+	if err = checkAffected(res); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM snoozed WHERE task_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to reset snooze: %w", err)
+	}
+	if next.Scheduled != "" && next.Scheduled != next.Date {
+		if _, err = tx.Exec(`INSERT INTO snoozed (task_id, anchor) VALUES (?, ?)`, id, next.Scheduled); err != nil {
+			return fmt.Errorf("failed to save anchor: %w", err)
+		}
+	}
+	return nil
+}
+
+// deleteTask removes the task for good, as opposed to trashTask.
+func deleteTask(tx *sql.Tx, id string) error {
+	res, err := tx.Exec(`DELETE FROM scheduler WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete task: %w", err)
+	}
+	if err = checkAffected(res); err != nil {
+		return err
+	}
+	return deleteTaskData(tx, id)
+}
+
+// deleteTaskData removes what other tables keep about the task.
+func deleteTaskData(tx *sql.Tx, id string) error {
+	if _, err := tx.Exec(`DELETE FROM checklist WHERE task_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete checklist: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM dependencies WHERE task_id = ? OR depends_on = ?`, id, id); err != nil {
+		return fmt.Errorf("failed to delete dependencies: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM reminders WHERE task_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete reminder: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM task_tags WHERE task_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete tags: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM snoozed WHERE task_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete snooze: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM repeat_modes WHERE task_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete repeat mode: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM exceptions WHERE task_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete exceptions: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM task_times WHERE task_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete task time: %w", err)
+	}
+	return nil
+}
+
+// inTx runs fn in a transaction that is committed when fn succeeds.
+func (s Store) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// versionIs matches a scheduler row at the version given as its argument.
+const versionIs = `COALESCE((SELECT version FROM task_versions WHERE task_id = scheduler.id), 1) = ?`
+
+// checkVersion tells apart a conditional change that matched nothing because
+// the task is gone from one that lost to a newer version.
+func checkVersion(tx *sql.Tx, res sql.Result, id string, version int) error {
+	err := checkAffected(res)
+	if !errors.Is(err, ErrNotFound) || version == 0 {
+		return err
+	}
+	if _, err = getTask(tx, id); err != nil {
+		return err
+	}
+	return ErrConflict
+}
+
+func checkAffected(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
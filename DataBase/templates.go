@@ -0,0 +1,103 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+// SaveTemplate creates a template or replaces the one with the same name.
+func (s Store) SaveTemplate(t models.Template) (string, error) {
+	checklist, err := json.Marshal(t.Checklist)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode checklist: %w", err)
+	}
+
+	var id int64
+	err = s.db.QueryRow(`INSERT INTO templates (name, title, comment, repeat, tags, checklist)
+        VALUES (?, ?, ?, ?, ?, ?)
+        ON CONFLICT (name) DO UPDATE SET title = excluded.title, comment = excluded.comment,
+            repeat = excluded.repeat, tags = excluded.tags, checklist = excluded.checklist
+        RETURNING id`,
+		t.Name, t.Title, t.Comment, t.Repeat, strings.Join(t.Tags, ","), string(checklist)).Scan(&id)
+	if err != nil {
+		return "", fmt.Errorf("failed to save template: %w", err)
+	}
+	return strconv.FormatInt(id, 10), nil
+}
+
+const templateColumns = `id, name, title, comment, repeat, tags, checklist`
+
+func scanTemplate(row scanner) (models.Template, error) {
+	var (
+		t               models.Template
+		tags, checklist string
+	)
+	if err := row.Scan(&t.Id, &t.Name, &t.Title, &t.Comment, &t.Repeat, &tags, &checklist); err != nil {
+		return models.Template{}, err
+	}
+	if tags != "" {
+		t.Tags = strings.Split(tags, ",")
+	}
+	if err := json.Unmarshal([]byte(checklist), &t.Checklist); err != nil {
+		return models.Template{}, fmt.Errorf("failed to decode checklist: %w", err)
+	}
+	return t, nil
+}
+
+func (s Store) Templates() ([]models.Template, error) {
+	rows, err := s.db.Query(`SELECT ` + templateColumns + ` FROM templates ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []models.Template{}
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan template: %w", err)
+		}
+		templates = append(templates, t)
+	}
+	return templates, rows.Err()
+}
+
+func (s Store) GetTemplate(id string) (models.Template, error) {
+	t, err := scanTemplate(s.db.QueryRow(`SELECT `+templateColumns+` FROM templates WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Template{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Template{}, fmt.Errorf("failed to get template: %w", err)
+	}
+	return t, nil
+}
+
+func (s Store) DeleteTemplate(id string) error {
+	res, err := s.db.Exec(`DELETE FROM templates WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	return checkAffected(res)
+}
+
+// AddTaskWithChecklist adds task together with its checklist items.
+func (b Batch) AddTaskWithChecklist(task models.Task, checklist []string) (string, error) {
+	id, err := addTask(b.tx, task)
+	if err != nil {
+		return "", err
+	}
+	for i, title := range checklist {
+		_, err = b.tx.Exec(`INSERT INTO checklist (task_id, position, title) VALUES (?, ?, ?)`, id, i+1, title)
+		if err != nil {
+			return "", fmt.Errorf("failed to add checklist item: %w", err)
+		}
+	}
+	return id, nil
+}
@@ -0,0 +1,134 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+// trashTask moves the task from scheduler to trash. Like updateTask it
+// requires the task to be at version unless that is 0.
+func trashTask(tx *sql.Tx, id string, version int, deletedAt string) error {
+	if _, err := tx.Exec(`INSERT OR REPLACE INTO trash (id, date, title, comment, repeat, version, deleted_at)
+        SELECT id, date, title, COALESCE(comment, ''), COALESCE(repeat, ''),
+            COALESCE((SELECT version FROM task_versions WHERE task_id = scheduler.id), 1), ?
+        FROM scheduler WHERE id = ?`, deletedAt, id); err != nil {
+		return fmt.Errorf("failed to trash task: %w", err)
+	}
+
+	query := `DELETE FROM scheduler WHERE id = ?`
+	args := []any{id}
+	if version != 0 {
+		query += ` AND ` + versionIs
+		args = append(args, version)
+	}
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to delete task: %w", err)
+	}
+	return checkVersion(tx, res, id, version)
+}
+
+// Trash returns the deleted tasks, most recently deleted first.
+func (s Store) Trash() ([]models.TrashedTask, error) {
+	rows, err := s.db.Query(`SELECT ` + trashColumns + ` FROM trash t ORDER BY t.deleted_at DESC, t.id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trash: %w", err)
+	}
+	return scanTrash(rows)
+}
+
+const trashColumns = `t.id, t.date, t.title, t.comment, t.repeat,
+    COALESCE((SELECT group_concat(tag, ',') FROM task_tags WHERE task_id = t.id), ''), t.deleted_at`
+
+func scanTrash(rows *sql.Rows) ([]models.TrashedTask, error) {
+	defer rows.Close()
+
+	tasks := []models.TrashedTask{}
+	for rows.Next() {
+		var (
+			task models.TrashedTask
+			tags string
+		)
+		if err := rows.Scan(&task.Id, &task.Date, &task.Title, &task.Comment, &task.Repeat, &tags, &task.DeletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan trashed task: %w", err)
+		}
+		if tags != "" {
+			task.Tags = strings.Split(tags, ",")
+			sort.Strings(task.Tags)
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get trash: %w", err)
+	}
+	return tasks, nil
+}
+
+// RestoreTask moves the task back from the trash under its old id, one
+// version past the one it was deleted at.
+func (b Batch) RestoreTask(id string) error {
+	res, err := b.tx.Exec(`INSERT INTO scheduler (id, date, title, comment, repeat)
+        SELECT id, date, title, comment, repeat FROM trash WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to restore task: %w", err)
+	}
+	if err = checkAffected(res); err != nil {
+		return err
+	}
+
+	if _, err = b.tx.Exec(`INSERT OR REPLACE INTO task_versions (task_id, version)
+        SELECT id, version + 1 FROM trash WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to restore task version: %w", err)
+	}
+	if _, err = b.tx.Exec(`DELETE FROM trash WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to restore task: %w", err)
+	}
+	return nil
+}
+
+// PurgeTask deletes a trashed task for good and returns it.
+func (b Batch) PurgeTask(id string) (models.TrashedTask, error) {
+	rows, err := b.tx.Query(`SELECT `+trashColumns+` FROM trash t WHERE t.id = ?`, id)
+	if err != nil {
+		return models.TrashedTask{}, fmt.Errorf("failed to get trash: %w", err)
+	}
+	tasks, err := scanTrash(rows)
+	if err != nil {
+		return models.TrashedTask{}, err
+	}
+	if len(tasks) == 0 {
+		return models.TrashedTask{}, ErrNotFound
+	}
+	return tasks[0], purgeTask(b.tx, id)
+}
+
+// PurgeTrash deletes for good the tasks trashed before the given time, or
+// all of them when before is empty, and returns them.
+func (b Batch) PurgeTrash(before string) ([]models.TrashedTask, error) {
+	rows, err := b.tx.Query(`SELECT `+trashColumns+` FROM trash t
+        WHERE ? = '' OR t.deleted_at < ? ORDER BY t.deleted_at, t.id`, before, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trash: %w", err)
+	}
+	tasks, err := scanTrash(rows)
+	if err != nil {
+		return nil, err
+	}
+	for _, task := range tasks {
+		if err = purgeTask(b.tx, task.Id); err != nil {
+			return nil, err
+		}
+	}
+	return tasks, nil
+}
+
+func purgeTask(tx *sql.Tx, id string) error {
+	if _, err := tx.Exec(`DELETE FROM trash WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to purge task: %w", err)
+	}
+	return deleteTaskData(tx, id)
+}
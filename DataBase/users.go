@@ -0,0 +1,38 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// SetPassword creates the user or replaces their password hash.
+func (s Store) SetPassword(name, hash string) error {
+	_, err := s.db.Exec(`INSERT INTO users (name, password_hash) VALUES (?, ?)
+        ON CONFLICT (name) DO UPDATE SET password_hash = excluded.password_hash`, name, hash)
+	if err != nil {
+		return fmt.Errorf("failed to set password: %w", err)
+	}
+	return nil
+}
+
+func (s Store) PasswordHash(name string) (string, error) {
+	var hash string
+	err := s.db.QueryRow(`SELECT password_hash FROM users WHERE name = ?`, name).Scan(&hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get user: %w", err)
+	}
+	return hash, nil
+}
+
+// HasUsers reports whether any user exists, which turns authentication on.
+func (s Store) HasUsers() (bool, error) {
+	var exists bool
+	if err := s.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM users)`).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check users: %w", err)
+	}
+	return exists, nil
+}
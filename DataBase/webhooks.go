@@ -0,0 +1,172 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+func (s Store) AddWebhook(wh models.Webhook) (string, error) {
+	res, err := s.db.Exec(`INSERT INTO webhooks (url, secret, events) VALUES (?, ?, ?)`,
+		wh.URL, wh.Secret, strings.Join(wh.Events, ","))
+	if err != nil {
+		return "", fmt.Errorf("failed to add webhook: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return "", fmt.Errorf("failed to get webhook id: %w", err)
+	}
+	return strconv.FormatInt(id, 10), nil
+}
+
+// Webhooks lists the registered webhooks including their secrets.
+func (s Store) Webhooks() ([]models.Webhook, error) {
+	rows, err := s.db.Query(`SELECT id, url, secret, events FROM webhooks ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	webhooks := []models.Webhook{}
+	for rows.Next() {
+		wh, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, wh)
+	}
+	return webhooks, rows.Err()
+}
+
+func (s Store) GetWebhook(id string) (models.Webhook, error) {
+	wh, err := scanWebhook(s.db.QueryRow(`SELECT id, url, secret, events FROM webhooks WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Webhook{}, ErrNotFound
+	}
+	if err != nil {
+		return models.Webhook{}, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return wh, nil
+}
+
+func scanWebhook(row scanner) (models.Webhook, error) {
+	var (
+		wh     models.Webhook
+		events string
+	)
+	if err := row.Scan(&wh.Id, &wh.URL, &wh.Secret, &events); err != nil {
+		return models.Webhook{}, err
+	}
+	wh.Events = []string{}
+	if events != "" {
+		wh.Events = strings.Split(events, ",")
+	}
+	return wh, nil
+}
+
+func (s Store) DeleteWebhook(id string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`DELETE FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+	if err = checkAffected(res); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`UPDATE webhook_deliveries SET status = ?, last_error = 'webhook deleted'
+        WHERE webhook_id = ? AND status = ?`, DeliveryFailed, id, DeliveryPending); err != nil {
+		return fmt.Errorf("failed to cancel deliveries: %w", err)
+	}
+	return tx.Commit()
+}
+
+// EnqueueDeliveries queues payload to be sent at once to every webhook that
+// subscribes to event, which is all of them with an empty event list.
+func (b Batch) EnqueueDeliveries(event, payload, now string) error {
+	_, err := b.tx.Exec(`INSERT INTO webhook_deliveries (webhook_id, event, payload, next_attempt, created_at)
+        SELECT id, ?, ?, ?, ? FROM webhooks
+        WHERE events = '' OR ',' || events || ',' LIKE '%,' || ? || ',%'`, event, payload, now, now, event)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue deliveries: %w", err)
+	}
+	return nil
+}
+
+// DueDeliveries returns pending deliveries whose next attempt is not after now.
+func (s Store) DueDeliveries(now string, limit int) ([]models.Delivery, error) {
+	return s.queryDeliveries(`WHERE status = ? AND next_attempt <= ? ORDER BY next_attempt, id LIMIT ?`,
+		DeliveryPending, now, limit)
+}
+
+// Deliveries returns the delivery log, newest first. Empty arguments don't filter.
+func (s Store) Deliveries(webhookID, status string, limit int) ([]models.Delivery, error) {
+	var (
+		where []string
+		args  []any
+	)
+	if webhookID != "" {
+		where = append(where, `webhook_id = ?`)
+		args = append(args, webhookID)
+	}
+	if status != "" {
+		where = append(where, `status = ?`)
+		args = append(args, status)
+	}
+
+	query := ``
+	if len(where) > 0 {
+		query = `WHERE ` + strings.Join(where, ` AND `)
+	}
+	return s.queryDeliveries(query+` ORDER BY id DESC LIMIT ?`, append(args, limit)...)
+}
+
+func (s Store) queryDeliveries(clause string, args ...any) ([]models.Delivery, error) {
+	rows, err := s.db.Query(`SELECT id, webhook_id, event, payload, status, attempts, next_attempt,
+            response_code, last_error, created_at, delivered_at
+        FROM webhook_deliveries `+clause, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.Delivery{}
+	for rows.Next() {
+		var d models.Delivery
+		err = rows.Scan(&d.Id, &d.WebhookId, &d.Event, &d.Payload, &d.Status, &d.Attempts, &d.NextAttempt,
+			&d.ResponseCode, &d.LastError, &d.CreatedAt, &d.DeliveredAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// FinishAttempt stores the outcome of a delivery attempt. A pending status
+// schedules the next attempt at nextAttempt.
+func (s Store) FinishAttempt(d models.Delivery) error {
+	_, err := s.db.Exec(`UPDATE webhook_deliveries SET status = ?, attempts = ?, next_attempt = ?,
+            response_code = ?, last_error = ?, delivered_at = ?
+        WHERE id = ?`,
+		d.Status, d.Attempts, d.NextAttempt, d.ResponseCode, d.LastError, d.DeliveredAt, d.Id)
+	if err != nil {
+		return fmt.Errorf("failed to update delivery: %w", err)
+	}
+	return nil
+}
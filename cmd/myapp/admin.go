@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/auth"
+	"github.com/paran0iaa/TODO/internal/events"
+	"github.com/paran0iaa/TODO/internal/models"
+	"github.com/paran0iaa/TODO/internal/services"
+)
+
+// adminCommands work on the database file directly and don't need a running
+// server. The default command, serve, starts the server.
+var adminCommands = map[string]func(store db.Store, args []string) error{
+	"migrate":        migrateCommand,
+	"export":         exportCommand,
+	"import":         importCommand,
+	"vacuum":         vacuumCommand,
+	"reset-password": resetPasswordCommand,
+}
+
+func adminCommandNames() []string {
+	names := make([]string, 0, len(adminCommands))
+	for name := range adminCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// export is the file format of export and import: a /api/tasks response
+// whose tasks also carry their checklist, dependencies, reminder and history.
+// Deleted tasks, the audit log, webhooks, users, saved filters and templates
+// are not exported.
+type export struct {
+	Tasks []models.TaskExport `json:"tasks"`
+}
+
+// migrateCommand applies the pending migrations. It is the only command that
+// gets the database unmigrated.
+func migrateCommand(store db.Store, args []string) error {
+	if len(args) > 0 {
+		return errors.New("usage: migrate")
+	}
+	applied, err := store.Migrate()
+	if err != nil {
+		return err
+	}
+	if applied == 0 {
+		fmt.Println("database is up to date")
+		return nil
+	}
+	fmt.Printf("applied %d migrations\n", applied)
+	return nil
+}
+
+// exportCommand writes every task with what is attached to it as JSON to the
+// file given with -o, or to stdout.
+func exportCommand(store db.Store, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	output := flags.String("o", "", "output file, stdout by default")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	tasks, err := store.ExportTasks()
+	if err != nil {
+		return err
+	}
+
+	w := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(export{Tasks: tasks})
+}
+
+// importCommand adds the tasks of an export file, or of stdin for -, as new
+// tasks. Dates, history and the rest are kept as they are, and dependencies
+// are remapped to the new ids; either every task is imported or none.
+func importCommand(store db.Store, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: import <file|->")
+	}
+
+	r := io.Reader(os.Stdin)
+	if args[0] != "-" {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	var data export
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return fmt.Errorf("invalid export file: %w", err)
+	}
+
+	exported := make(map[string]bool)
+	for i, task := range data.Tasks {
+		if exported[task.Id] {
+			return fmt.Errorf("task %d: duplicate id %s", i, task.Id)
+		}
+		if task.Id != "" {
+			exported[task.Id] = true
+		}
+	}
+	for i := range data.Tasks {
+		if err := checkImport(&data.Tasks[i], exported); err != nil {
+			return fmt.Errorf("task %d: %w", i, err)
+		}
+	}
+
+	now := services.Now().Format(time.RFC3339)
+	err := store.Batch(func(b db.Batch) error {
+		ids := make(map[string]string)
+		for _, task := range data.Tasks {
+			id, err := b.ImportTask(task)
+			if err != nil {
+				return err
+			}
+			if task.Id != "" {
+				ids[task.Id] = id
+			}
+			task.Task.Id = id
+			event := events.Event{Type: events.TaskCreated, Time: now, Task: task.Task}
+			if err = b.AddAuditEntry(services.AuditEntry(event)); err != nil {
+				return err
+			}
+		}
+		for _, task := range data.Tasks {
+			for _, dep := range task.Dependencies {
+				if err := b.AddDependency(ids[task.Id], ids[dep]); err != nil {
+					return fmt.Errorf("dependency of %s on %s: %w", task.Id, dep, err)
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("imported %d tasks\n", len(data.Tasks))
+	return nil
+}
+
+// checkImport validates an exported task the way the API would have when it
+// was created. Its dependencies must be tasks of the same export.
+func checkImport(task *models.TaskExport, exported map[string]bool) error {
+	date, err := time.Parse(models.Layout, task.Date)
+	if err != nil {
+		return fmt.Errorf("invalid date: %s", task.Date)
+	}
+	// Checking against the task's own date validates it without moving it
+	// to today.
+	if err = services.CheckTask(&task.Task, date); err != nil {
+		return err
+	}
+
+	dates := []string{task.Anchor}
+	for _, e := range task.Exceptions {
+		dates = append(dates, e.Date, e.MovedTo)
+	}
+	for _, c := range task.History {
+		dates = append(dates, c.Date)
+		if _, err = time.Parse(time.RFC3339, c.DoneAt); err != nil {
+			return fmt.Errorf("invalid completion time: %s", c.DoneAt)
+		}
+	}
+	for _, d := range dates {
+		if _, err = time.Parse(models.Layout, d); d != "" && err != nil {
+			return fmt.Errorf("invalid date: %s", d)
+		}
+	}
+
+	for _, item := range task.Checklist {
+		if item.Title == "" {
+			return errors.New("checklist item title is required")
+		}
+	}
+	if task.Reminder != nil {
+		if err = services.CheckReminder(*task.Reminder); err != nil {
+			return err
+		}
+	}
+	for _, dep := range task.Dependencies {
+		if task.Id == "" || !exported[dep] {
+			return fmt.Errorf("unknown dependency: %s", dep)
+		}
+	}
+	return nil
+}
+
+func vacuumCommand(store db.Store, args []string) error {
+	if len(args) > 0 {
+		return errors.New("usage: vacuum")
+	}
+	return store.Vacuum()
+}
+
+// resetPasswordCommand sets a user's password, creating the user if needed,
+// which turns authentication on. The password is read from TODO_PASSWORD or
+// from the first line of stdin.
+func resetPasswordCommand(store db.Store, args []string) error {
+	flags := flag.NewFlagSet("reset-password", flag.ContinueOnError)
+	user := flags.String("user", auth.DefaultUser, "user name")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	password := os.Getenv("TODO_PASSWORD")
+	if password == "" {
+		fmt.Fprint(os.Stderr, "new password: ")
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+	if password == "" {
+		return errors.New("password must not be empty")
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	if err = store.SetPassword(*user, hash); err != nil {
+		return err
+	}
+	fmt.Printf("password of %s is set\n", *user)
+	return nil
+}
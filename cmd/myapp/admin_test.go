@@ -0,0 +1,132 @@
+package main
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+func tempStore(t *testing.T, open func(string) *sql.DB) db.Store {
+	sqlDB := open(filepath.Join(t.TempDir(), "scheduler.db"))
+	t.Cleanup(func() { sqlDB.Close() })
+	return db.NewStore(sqlDB)
+}
+
+// normalize replaces the ids of an export by task titles so that exports of
+// different databases can be compared.
+func normalize(tasks []models.TaskExport) []models.TaskExport {
+	titles := make(map[string]string)
+	for _, task := range tasks {
+		titles[task.Id] = task.Title
+	}
+	for i := range tasks {
+		task := &tasks[i]
+		task.Id, task.Version = "", 0
+		for j := range task.Checklist {
+			task.Checklist[j].Id, task.Checklist[j].TaskId = "", ""
+		}
+		for j, dep := range task.Dependencies {
+			task.Dependencies[j] = titles[dep]
+		}
+		if task.Reminder != nil {
+			task.Reminder.TaskId = ""
+		}
+		for j := range task.History {
+			task.History[j].Id, task.History[j].TaskId = "", ""
+		}
+	}
+	return tasks
+}
+
+func TestExportImport(t *testing.T) {
+	src := tempStore(t, db.CreateDb)
+
+	var weekly, report string
+	require.NoError(t, src.Batch(func(b db.Batch) error {
+		var err error
+		weekly, err = b.AddTask(models.Task{Date: "20240303", Title: "Уборка", Repeat: "d 7",
+			Tags: []string{"дом"}, Time: "10:00", Estimate: 60, RepeatFrom: models.RepeatFromCompletion})
+		if err != nil {
+			return err
+		}
+		for _, c := range []models.Completion{
+			{Date: "20240225", DoneAt: "2024-02-25T11:00:00+03:00"},
+			{Date: "20240303", DoneAt: "2024-03-04T09:30:00+03:00", Note: "с опозданием"},
+		} {
+			c.TaskId, c.Title = weekly, "Уборка"
+			if err = b.CompleteTask(c, models.Occurrence{Date: "20240310", Scheduled: "20240310"}); err != nil {
+				return err
+			}
+		}
+		task, err := b.GetTask(weekly)
+		if err != nil {
+			return err
+		}
+		if err = b.SnoozeTask(task, "20240312"); err != nil {
+			return err
+		}
+		if err = b.SaveException(weekly, models.Exception{Date: "20240324", MovedTo: "20240325"}, nil); err != nil {
+			return err
+		}
+		if err = b.SaveException(weekly, models.Exception{Date: "20240331"}, nil); err != nil {
+			return err
+		}
+		report, err = b.AddTask(models.Task{Date: "20240315", Title: "Отчёт", Comment: "квартальный"})
+		return err
+	}))
+	item, err := src.AddChecklistItem(weekly, "Пропылесосить")
+	require.NoError(t, err)
+	_, err = src.AddChecklistItem(weekly, "Вынести мусор")
+	require.NoError(t, err)
+	require.NoError(t, src.SetChecklistItemDone(item, true))
+	require.NoError(t, src.AddDependency(report, weekly))
+	require.NoError(t, src.SetReminder(models.Reminder{TaskId: weekly, DaysBefore: 1, At: "09:00",
+		Channel: "email", Target: "me@example.com"}))
+	require.NoError(t, src.MarkReminderSent(weekly, "20240312"))
+
+	file := filepath.Join(t.TempDir(), "export.json")
+	require.NoError(t, exportCommand(src, []string{"-o", file}))
+
+	dst := tempStore(t, db.CreateDb)
+	require.NoError(t, importCommand(dst, []string{file}))
+
+	want, err := src.ExportTasks()
+	require.NoError(t, err)
+	got, err := dst.ExportTasks()
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	assert.Equal(t, "20240310", got[0].Anchor)
+	assert.Len(t, got[0].Exceptions, 2)
+	assert.Len(t, got[0].History, 2)
+	assert.Equal(t, normalize(want), normalize(got))
+
+	// A failed import adds nothing.
+	cycle := `{"tasks": [
+        {"id": "1", "date": "20240301", "title": "A", "repeat": "", "dependencies": ["2"]},
+        {"id": "2", "date": "20240301", "title": "B", "repeat": "", "dependencies": ["1"]}
+    ]}`
+	require.NoError(t, os.WriteFile(file, []byte(cycle), 0o600))
+	assert.ErrorIs(t, importCommand(dst, []string{file}), db.ErrDependencyCycle)
+	got, err = dst.ExportTasks()
+	require.NoError(t, err)
+	assert.Len(t, got, 2)
+}
+
+func TestMigrateCommand(t *testing.T) {
+	store := tempStore(t, db.OpenDb)
+
+	applied, err := store.Migrate()
+	require.NoError(t, err)
+	assert.Positive(t, applied)
+
+	applied, err = store.Migrate()
+	require.NoError(t, err)
+	assert.Zero(t, applied)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"crypto/rand"
+	"log"
+	"os"
+	"time"
+
+	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/auth"
+)
+
+const tokenTTL = 8 * time.Hour
+
+// tokens signs with TODO_SECRET, or with a random key when it is unset, in
+// which case tokens don't survive a restart.
+func tokens() auth.Tokens {
+	secret := []byte(os.Getenv("TODO_SECRET"))
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatalf("failed to generate token secret: %v", err)
+		}
+	}
+	return auth.NewTokens(secret, tokenTTL)
+}
+
+// bootstrapUser sets the password of the default user from TODO_PASSWORD,
+// which turns authentication on.
+func bootstrapUser(store db.Store) {
+	password := os.Getenv("TODO_PASSWORD")
+	if password == "" {
+		return
+	}
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		log.Fatalf("failed to hash TODO_PASSWORD: %v", err)
+	}
+	if err = store.SetPassword(auth.DefaultUser, hash); err != nil {
+		log.Fatalf("failed to set TODO_PASSWORD: %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/paran0iaa/TODO/internal/services"
+)
+
+const defaultDayMinutes = 8 * 60
+
+// dayCapacity is the workload the forecast allows per day: TODO_DAY_MINUTES
+// estimated minutes, eight hours by default, and TODO_DAY_TASKS tasks. Zero
+// lifts a limit.
+func dayCapacity() services.Capacity {
+	capacity := services.Capacity{Minutes: defaultDayMinutes}
+	for name, dest := range map[string]*int{
+		"TODO_DAY_MINUTES": &capacity.Minutes,
+		"TODO_DAY_TASKS":   &capacity.Tasks,
+	} {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			log.Fatalf("%s: invalid number: %s", name, value)
+		}
+		*dest = n
+	}
+	return capacity
+}
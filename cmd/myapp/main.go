@@ -1,24 +1,128 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
+	"os"
+	"strings"
 
 	"github.com/gorilla/mux"
 	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/events"
 	"github.com/paran0iaa/TODO/internal/handlers"
 	"github.com/paran0iaa/TODO/internal/services"
 )
 
+// eventHistory is how many recent events /api/events keeps for resuming.
+const eventHistory = 1000
+
 func main() {
-	db.CreateDb(services.GetEnv("TODO_DBFILE"))
+	if date, ok := os.LookupEnv("TODO_NOW"); ok {
+		if err := services.FixClock(date); err != nil {
+			log.Fatalf("TODO_NOW: %v", err)
+		}
+	}
+
+	cmd, args := "serve", []string(nil)
+	if len(os.Args) > 1 {
+		cmd, args = os.Args[1], os.Args[2:]
+	}
+	run, ok := adminCommands[cmd]
+	if cmd != "serve" && !ok {
+		log.Fatalf("unknown command %q; expected serve, %s", cmd, strings.Join(adminCommandNames(), ", "))
+	}
+
+	// Opening the database applies any pending migrations, except for
+	// migrate, which reports them.
+	open := db.CreateDb
+	if cmd == "migrate" {
+		open = db.OpenDb
+	}
+	sqlDB := open(services.GetEnv("TODO_DBFILE"))
+	defer sqlDB.Close()
+	store := db.NewStore(sqlDB)
+
+	if cmd != "serve" {
+		if err := run(store, args); err != nil {
+			sqlDB.Close()
+			log.Fatalf("%s: %v", cmd, err)
+		}
+		return
+	}
+	serve(store)
+}
+
+func serve(store db.Store) {
+	bootstrapUser(store)
+	tokens := tokens()
+	channels := notifiers()
+	bus := events.NewBus()
+	hub := events.NewHub(eventHistory)
+	bus.Subscribe(hub.Publish)
+	wake := make(chan struct{}, 1)
+	wakeWebhooks(bus, wake)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runReminders(ctx, store, channels)
+	go runWebhooks(ctx, store, wake)
+	go runTrashPurge(ctx, store, bus, trashRetention())
 
 	r := mux.NewRouter()
 	r.HandleFunc("/api/nextdate", handlers.NextDateHandler).Methods("GET")
-	r.HandleFunc("/api/task", handlers.CreateTask).Methods("POST")
-	http.Handle("/", r)
+	r.HandleFunc("/api/signin", handlers.SignIn(store, tokens)).Methods("POST")
+
+	api := r.PathPrefix("/api").Subrouter()
+	api.Use(handlers.RequireAuth(store, tokens))
+	api.HandleFunc("/tasks", handlers.GetTasks(store)).Methods("GET")
+	api.HandleFunc("/tasks/bulk", handlers.BulkTasks(store, bus)).Methods("POST")
+	api.HandleFunc("/events", handlers.Events(hub)).Methods("GET")
+	api.HandleFunc("/stats", handlers.GetStats(store)).Methods("GET")
+	api.HandleFunc("/calendar", handlers.GetCalendar(store)).Methods("GET")
+	api.HandleFunc("/forecast", handlers.GetForecast(store, dayCapacity())).Methods("GET")
+	api.HandleFunc("/task", handlers.GetTask(store)).Methods("GET")
+	api.HandleFunc("/task", handlers.CreateTask(store, bus)).Methods("POST")
+	api.HandleFunc("/task", handlers.UpdateTask(store, bus)).Methods("PUT")
+	api.HandleFunc("/task", handlers.PatchTask(store, bus)).Methods("PATCH")
+	api.HandleFunc("/task", handlers.DeleteTask(store, bus)).Methods("DELETE")
+	api.HandleFunc("/task/quick", handlers.QuickAdd(store, bus)).Methods("POST")
+	api.HandleFunc("/task/done", handlers.TaskDone(store, bus)).Methods("POST")
+	api.HandleFunc("/task/snooze", handlers.SnoozeTask(store, bus)).Methods("POST")
+	api.HandleFunc("/task/exceptions", handlers.GetExceptions(store)).Methods("GET")
+	api.HandleFunc("/task/exceptions", handlers.AddException(store, bus)).Methods("POST")
+	api.HandleFunc("/task/exceptions", handlers.DeleteException(store, bus)).Methods("DELETE")
+	api.HandleFunc("/task/occurrences", handlers.GetOccurrences(store)).Methods("GET")
+	api.HandleFunc("/task/history", handlers.TaskHistory(store)).Methods("GET")
+	api.HandleFunc("/task/checklist", handlers.GetChecklist(store)).Methods("GET")
+	api.HandleFunc("/task/checklist", handlers.AddChecklistItem(store, bus)).Methods("POST")
+	api.HandleFunc("/task/checklist", handlers.DeleteChecklistItem(store, bus)).Methods("DELETE")
+	api.HandleFunc("/task/checklist/done", handlers.ChecklistItemDone(store, bus)).Methods("POST")
+	api.HandleFunc("/task/dependencies", handlers.GetDependencies(store)).Methods("GET")
+	api.HandleFunc("/task/dependencies", handlers.AddDependency(store, bus)).Methods("POST")
+	api.HandleFunc("/task/dependencies", handlers.DeleteDependency(store, bus)).Methods("DELETE")
+	api.HandleFunc("/task/reminder", handlers.GetReminder(store)).Methods("GET")
+	api.HandleFunc("/task/reminder", handlers.SetReminder(store, bus, channels)).Methods("PUT")
+	api.HandleFunc("/task/reminder", handlers.DeleteReminder(store, bus)).Methods("DELETE")
+	api.HandleFunc("/webhooks", handlers.GetWebhooks(store)).Methods("GET")
+	api.HandleFunc("/webhooks", handlers.AddWebhook(store)).Methods("POST")
+	api.HandleFunc("/webhooks", handlers.DeleteWebhook(store)).Methods("DELETE")
+	api.HandleFunc("/webhooks/deliveries", handlers.GetDeliveries(store)).Methods("GET")
+	api.HandleFunc("/audit", handlers.GetAudit(store)).Methods("GET")
+	api.HandleFunc("/trash", handlers.GetTrash(store)).Methods("GET")
+	api.HandleFunc("/trash", handlers.PurgeTrash(store, bus)).Methods("DELETE")
+	api.HandleFunc("/trash/restore", handlers.RestoreTask(store, bus)).Methods("POST")
+	api.HandleFunc("/filters", handlers.GetFilters(store)).Methods("GET")
+	api.HandleFunc("/filters", handlers.SaveFilter(store)).Methods("POST")
+	api.HandleFunc("/filters", handlers.DeleteFilter(store)).Methods("DELETE")
+	api.HandleFunc("/templates", handlers.GetTemplates(store)).Methods("GET")
+	api.HandleFunc("/templates", handlers.SaveTemplate(store)).Methods("POST")
+	api.HandleFunc("/templates", handlers.DeleteTemplate(store)).Methods("DELETE")
+	api.HandleFunc("/templates/instantiate", handlers.InstantiateTemplate(store, bus)).Methods("POST")
+
+	r.PathPrefix("/").Handler(handlers.WebDir())
 
-	if err := http.ListenAndServe(":"+services.GetEnv("TODO_PORT"), handlers.WebDir()); err != nil {
+	if err := http.ListenAndServe(":"+services.GetEnv("TODO_PORT"), r); err != nil {
 		log.Fatalf("ListenAndServe: %v\n", err)
 	}
 }
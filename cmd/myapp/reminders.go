@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/models"
+	"github.com/paran0iaa/TODO/internal/notify"
+	"github.com/paran0iaa/TODO/internal/services"
+)
+
+const (
+	reminderInterval    = time.Minute
+	reminderMaxAttempts = 6
+	reminderBaseBackoff = time.Minute
+	reminderMaxBackoff  = time.Hour
+	notifyTimeout       = 30 * time.Second
+)
+
+// notifiers returns the reminder channels. Webhooks are always available;
+// email is enabled by TODO_SMTP_ADDR.
+func notifiers() map[string]notify.Notifier {
+	channels := map[string]notify.Notifier{
+		"webhook": notify.Webhook{Client: &http.Client{Timeout: notifyTimeout}},
+	}
+	if addr := os.Getenv("TODO_SMTP_ADDR"); addr != "" {
+		channels["email"] = notify.SMTP{
+			Addr:     addr,
+			From:     os.Getenv("TODO_SMTP_FROM"),
+			Username: os.Getenv("TODO_SMTP_USER"),
+			Password: os.Getenv("TODO_SMTP_PASSWORD"),
+		}
+	}
+	return channels
+}
+
+func runReminders(ctx context.Context, store db.Store, channels map[string]notify.Notifier) {
+	ticker := time.NewTicker(reminderInterval)
+	defer ticker.Stop()
+
+	for {
+		dispatchReminders(ctx, store, channels, services.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatchReminders sends every reminder that is due at now. A failed
+// delivery is retried with exponential backoff, and the occurrence is given
+// up on after reminderMaxAttempts.
+func dispatchReminders(ctx context.Context, store db.Store, channels map[string]notify.Notifier, now time.Time) {
+	pending, err := store.PendingReminders()
+	if err != nil {
+		log.Printf("reminders: %v", err)
+		return
+	}
+
+	for _, p := range pending {
+		due, err := services.ReminderDue(p.Reminder, p.Task, now.Location())
+		if err != nil {
+			log.Printf("reminders: task %s: %v", p.Task.Id, err)
+			continue
+		}
+		if due.After(now) || p.NextAttempt > now.UTC().Format(time.RFC3339) {
+			continue
+		}
+
+		notifier, ok := channels[p.Reminder.Channel]
+		if !ok {
+			log.Printf("reminders: task %s: channel %s is not configured", p.Task.Id, p.Reminder.Channel)
+			continue
+		}
+
+		sendCtx, cancel := context.WithTimeout(ctx, notifyTimeout)
+		err = notifier.Notify(sendCtx, p.Reminder.Target, reminderMessage(p.Task))
+		cancel()
+		if err != nil {
+			log.Printf("reminders: task %s: %v", p.Task.Id, err)
+			err = retryReminder(store, p, now)
+		} else {
+			err = store.MarkReminderSent(p.Task.Id, p.Task.Date)
+		}
+		if err != nil {
+			log.Printf("reminders: %v", err)
+		}
+	}
+}
+
+// retryReminder schedules the next attempt at a reminder that failed to
+// send, or marks it sent once it has failed reminderMaxAttempts times.
+func retryReminder(store db.Store, p db.PendingReminder, now time.Time) error {
+	attempts := p.Attempts + 1
+	if attempts >= reminderMaxAttempts {
+		log.Printf("reminders: task %s: giving up on %s after %d attempts", p.Task.Id, p.Task.Date, attempts)
+		return store.MarkReminderSent(p.Task.Id, p.Task.Date)
+	}
+	backoff := min(reminderBaseBackoff<<(attempts-1), reminderMaxBackoff)
+	return store.RetryReminder(p.Task.Id, attempts, now.Add(backoff).UTC().Format(time.RFC3339))
+}
+
+func reminderMessage(task models.Task) notify.Message {
+	date, _ := time.Parse(models.Layout, task.Date)
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "%s is due on %s.\n", task.Title, date.Format("02.01.2006"))
+	if task.Comment != "" {
+		fmt.Fprintf(&body, "\n%s\n", task.Comment)
+	}
+	return notify.Message{
+		Subject: "Reminder: " + task.Title,
+		Body:    body.String(),
+		Task:    task,
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/models"
+	"github.com/paran0iaa/TODO/internal/notify"
+)
+
+type failingNotifier struct {
+	calls *int
+}
+
+func (n failingNotifier) Notify(context.Context, string, notify.Message) error {
+	*n.calls++
+	return errors.New("smtp: connection refused")
+}
+
+func TestDispatchRemindersBackoff(t *testing.T) {
+	sqlDB := db.CreateDb(filepath.Join(t.TempDir(), "scheduler.db"))
+	defer sqlDB.Close()
+	store := db.NewStore(sqlDB)
+
+	var id string
+	require.NoError(t, store.Batch(func(b db.Batch) error {
+		var err error
+		id, err = b.AddTask(models.Task{Date: "20240310", Title: "Отчёт"})
+		return err
+	}))
+	require.NoError(t, store.SetReminder(models.Reminder{TaskId: id, At: "08:00",
+		Channel: "email", Target: "me@example.com"}))
+
+	calls := 0
+	channels := map[string]notify.Notifier{"email": failingNotifier{calls: &calls}}
+	now := time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC)
+	pending := func() []db.PendingReminder {
+		p, err := store.PendingReminders()
+		require.NoError(t, err)
+		return p
+	}
+
+	dispatchReminders(context.Background(), store, channels, now)
+	assert.Equal(t, 1, calls)
+	if p := pending(); assert.Len(t, p, 1) {
+		assert.Equal(t, 1, p[0].Attempts)
+		assert.Equal(t, now.Add(reminderBaseBackoff).Format(time.RFC3339), p[0].NextAttempt)
+	}
+
+	// Before the backoff is over nothing is sent.
+	dispatchReminders(context.Background(), store, channels, now.Add(reminderBaseBackoff-time.Second))
+	assert.Equal(t, 1, calls)
+
+	for i := 2; i <= reminderMaxAttempts; i++ {
+		now = now.Add(reminderMaxBackoff)
+		dispatchReminders(context.Background(), store, channels, now)
+		assert.Equal(t, i, calls)
+	}
+	// The occurrence is given up on after the last attempt.
+	assert.Empty(t, pending())
+	dispatchReminders(context.Background(), store, channels, now.Add(reminderMaxBackoff))
+	assert.Equal(t, reminderMaxAttempts, calls)
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/events"
+	"github.com/paran0iaa/TODO/internal/handlers"
+	"github.com/paran0iaa/TODO/internal/models"
+	"github.com/paran0iaa/TODO/internal/services"
+)
+
+const (
+	trashPurgeInterval = time.Hour
+	defaultTrashDays   = 30
+)
+
+// trashRetention is how long deleted tasks stay in the trash, set in days
+// by TODO_TRASH_DAYS. Zero keeps them until they are purged by hand.
+func trashRetention() time.Duration {
+	days := defaultTrashDays
+	if value, ok := os.LookupEnv("TODO_TRASH_DAYS"); ok {
+		var err error
+		days, err = strconv.Atoi(value)
+		if err != nil || days < 0 {
+			log.Fatalf("TODO_TRASH_DAYS: invalid number of days: %s", value)
+		}
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// runTrashPurge purges the tasks that have been in the trash for longer than
+// retention, recording them like a purge through the API.
+func runTrashPurge(ctx context.Context, store db.Store, bus *events.Bus, retention time.Duration) {
+	if retention == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		before := services.Now().Add(-retention).Format(time.RFC3339)
+		purged, err := handlers.Purge(store, bus, "", func(b db.Batch) ([]models.TrashedTask, error) {
+			return b.PurgeTrash(before)
+		})
+		if err != nil {
+			log.Printf("trash: %v", err)
+		} else if purged > 0 {
+			log.Printf("trash: purged %d tasks deleted before %s", purged, before)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
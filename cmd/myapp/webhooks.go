@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/events"
+	"github.com/paran0iaa/TODO/internal/models"
+	"github.com/paran0iaa/TODO/internal/notify"
+	"github.com/paran0iaa/TODO/internal/services"
+)
+
+const (
+	webhookInterval    = 5 * time.Second
+	webhookBatch       = 20
+	webhookMaxAttempts = 8
+	webhookBaseBackoff = 30 * time.Second
+	webhookMaxBackoff  = time.Hour
+	webhookTimeout     = 10 * time.Second
+	webhookWorkers     = 4
+)
+
+// wakeWebhooks wakes the delivery loop on every event. The write handlers
+// queue an event's deliveries in the transaction of the change, so they are
+// in the database by the time it is published.
+func wakeWebhooks(bus *events.Bus, wake chan<- struct{}) {
+	bus.Subscribe(func(events.Event) {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	})
+}
+
+func runWebhooks(ctx context.Context, store db.Store, wake <-chan struct{}) {
+	client := &http.Client{Timeout: webhookTimeout}
+	ticker := time.NewTicker(webhookInterval)
+	defer ticker.Stop()
+
+	for {
+		deliverWebhooks(ctx, store, client)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wake:
+		}
+	}
+}
+
+// deliverWebhooks makes one attempt at every due delivery, running up to
+// webhookWorkers of them at a time so that a slow endpoint doesn't hold up
+// the rest. Failures are retried with exponential backoff until
+// webhookMaxAttempts is reached.
+func deliverWebhooks(ctx context.Context, store db.Store, client *http.Client) {
+	now := services.Now().UTC()
+	due, err := store.DueDeliveries(now.Format(time.RFC3339), webhookBatch)
+	if err != nil {
+		log.Printf("webhooks: %v", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, webhookWorkers)
+	for _, d := range due {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			deliverWebhook(ctx, store, client, d, now)
+		}()
+	}
+	wg.Wait()
+}
+
+func deliverWebhook(ctx context.Context, store db.Store, client *http.Client, d models.Delivery, now time.Time) {
+	d.Attempts++
+
+	wh, err := store.GetWebhook(d.WebhookId)
+	if errors.Is(err, db.ErrNotFound) {
+		d.Status = db.DeliveryFailed
+		d.LastError = "webhook deleted"
+	} else if err != nil {
+		log.Printf("webhooks: %v", err)
+		return
+	} else {
+		d.ResponseCode, err = notify.Deliver(ctx, client, wh.URL, wh.Secret, d.Id, d.Event, []byte(d.Payload))
+		finishDelivery(&d, err, now)
+	}
+
+	if err = store.FinishAttempt(d); err != nil {
+		log.Printf("webhooks: %v", err)
+	}
+}
+
+func finishDelivery(d *models.Delivery, err error, now time.Time) {
+	if err == nil {
+		d.Status = db.DeliveryDelivered
+		d.LastError = ""
+		d.DeliveredAt = now.Format(time.RFC3339)
+		return
+	}
+
+	d.LastError = err.Error()
+	if d.Attempts >= webhookMaxAttempts {
+		d.Status = db.DeliveryFailed
+		return
+	}
+
+	backoff := min(webhookBaseBackoff<<(d.Attempts-1), webhookMaxBackoff)
+	d.NextAttempt = now.Add(backoff).Format(time.RFC3339)
+}
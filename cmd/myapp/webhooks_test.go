@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/models"
+	"github.com/paran0iaa/TODO/internal/services"
+)
+
+func TestFinishDelivery(t *testing.T) {
+	now := time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC)
+	for _, v := range []struct {
+		attempts int
+		next     time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{7, 32 * time.Minute},
+	} {
+		d := models.Delivery{Status: db.DeliveryPending, Attempts: v.attempts}
+		finishDelivery(&d, errors.New("webhook returned 500"), now)
+		assert.Equal(t, db.DeliveryPending, d.Status)
+		assert.Equal(t, now.Add(v.next).Format(time.RFC3339), d.NextAttempt, v.attempts)
+	}
+
+	d := models.Delivery{Status: db.DeliveryPending, Attempts: webhookMaxAttempts}
+	finishDelivery(&d, errors.New("webhook returned 500"), now)
+	assert.Equal(t, db.DeliveryFailed, d.Status)
+
+	d = models.Delivery{Status: db.DeliveryPending, Attempts: 3, LastError: "timeout"}
+	finishDelivery(&d, nil, now)
+	assert.Equal(t, db.DeliveryDelivered, d.Status)
+	assert.Empty(t, d.LastError)
+	assert.Equal(t, now.Format(time.RFC3339), d.DeliveredAt)
+}
+
+func TestDeliverWebhooks(t *testing.T) {
+	sqlDB := db.CreateDb(filepath.Join(t.TempDir(), "scheduler.db"))
+	defer sqlDB.Close()
+	store := db.NewStore(sqlDB)
+
+	now := time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC)
+	defer func(clock func() time.Time) { services.Now = clock }(services.Now)
+	services.Now = func() time.Time { return now }
+
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	_, err := store.AddWebhook(models.Webhook{URL: srv.URL, Secret: "secret"})
+	require.NoError(t, err)
+	require.NoError(t, store.Batch(func(b db.Batch) error {
+		return b.EnqueueDeliveries("task.created", `{"type":"task.created"}`, now.Format(time.RFC3339))
+	}))
+
+	deliver := func() models.Delivery {
+		deliverWebhooks(context.Background(), store, srv.Client())
+		deliveries, err := store.Deliveries("", "", 10)
+		require.NoError(t, err)
+		require.Len(t, deliveries, 1)
+		return deliveries[0]
+	}
+
+	d := deliver()
+	assert.Equal(t, db.DeliveryPending, d.Status)
+	assert.Equal(t, 1, d.Attempts)
+	assert.Equal(t, http.StatusServiceUnavailable, d.ResponseCode)
+	assert.Equal(t, now.Add(webhookBaseBackoff).Format(time.RFC3339), d.NextAttempt)
+
+	// Before the backoff is over nothing is sent.
+	now = now.Add(webhookBaseBackoff - time.Second)
+	d = deliver()
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 1, d.Attempts)
+
+	now = now.Add(time.Second)
+	d = deliver()
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, db.DeliveryDelivered, d.Status)
+	assert.Equal(t, 2, d.Attempts)
+	assert.Equal(t, now.Format(time.RFC3339), d.DeliveredAt)
+}
+
+func TestDeliverWebhooksConcurrently(t *testing.T) {
+	sqlDB := db.CreateDb(filepath.Join(t.TempDir(), "scheduler.db"))
+	defer sqlDB.Close()
+	store := db.NewStore(sqlDB)
+
+	var mu sync.Mutex
+	inFlight, most := 0, 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		most = max(most, inFlight)
+		mu.Unlock()
+		time.Sleep(50 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	for range 2 * webhookWorkers {
+		_, err := store.AddWebhook(models.Webhook{URL: srv.URL, Secret: "secret"})
+		require.NoError(t, err)
+	}
+	require.NoError(t, store.Batch(func(b db.Batch) error {
+		return b.EnqueueDeliveries("task.created", `{"type":"task.created"}`, services.Now().UTC().Format(time.RFC3339))
+	}))
+
+	deliverWebhooks(context.Background(), store, srv.Client())
+	deliveries, err := store.Deliveries("", "", 20)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2*webhookWorkers)
+	for _, d := range deliveries {
+		assert.Equal(t, db.DeliveryDelivered, d.Status)
+	}
+	assert.Greater(t, most, 1)
+	assert.LessOrEqual(t, most, webhookWorkers)
+}
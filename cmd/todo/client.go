@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const requestTimeout = 30 * time.Second
+
+// client calls the scheduler API, authenticating with a bearer token when it
+// has one.
+type client struct {
+	base  string
+	token string
+	http  *http.Client
+}
+
+func newClient(base, token string) *client {
+	return &client{
+		base:  strings.TrimSuffix(base, "/"),
+		token: token,
+		http:  &http.Client{Timeout: requestTimeout},
+	}
+}
+
+// apiError is an error response of the API.
+type apiError struct {
+	status  int
+	message string
+}
+
+func (e *apiError) Error() string {
+	if e.message == "" {
+		return http.StatusText(e.status)
+	}
+	return e.message
+}
+
+// do sends body, if any, as JSON and decodes the response into out, if any.
+// A *json.RawMessage out receives the response as is, and a *string out
+// receives a plain text response.
+func (c *client) do(method, path string, query url.Values, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	u := c.base + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequest(method, u, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		_ = json.Unmarshal(data, &e)
+		return &apiError{status: resp.StatusCode, message: e.Error}
+	}
+
+	switch out := out.(type) {
+	case nil:
+		return nil
+	case *string:
+		*out = strings.TrimSpace(string(data))
+		return nil
+	}
+	if err = json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+func (c *client) signIn(login, password string) error {
+	var resp struct {
+		Token string `json:"token"`
+	}
+	creds := map[string]string{"login": login, "password": password}
+	if err := c.do(http.MethodPost, "/api/signin", nil, creds, &resp); err != nil {
+		return err
+	}
+	c.token = resp.Token
+	return nil
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+// newFlags returns a flag set for a subcommand that reports errors instead
+// of printing them.
+func newFlags(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	return flags
+}
+
+func parse(flags *flag.FlagSet, args []string) error {
+	if err := flags.Parse(args); err != nil {
+		return usageError{err.Error()}
+	}
+	return nil
+}
+
+func splitTags(tags string) []string {
+	if tags == "" {
+		return []string{}
+	}
+	return strings.Split(tags, ",")
+}
+
+func cmdAdd(a *app, args []string) error {
+	flags := newFlags("add")
+	var task models.Task
+	flags.StringVar(&task.Date, "date", "", "date as 20060102, today by default")
+	flags.StringVar(&task.Repeat, "repeat", "", "repeat rule")
+	flags.StringVar(&task.Comment, "comment", "", "comment")
+	tags := flags.String("tags", "", "comma-separated tags")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	task.Title = strings.Join(flags.Args(), " ")
+	if task.Title == "" {
+		return usageError{"title is required"}
+	}
+	task.Tags = splitTags(*tags)
+
+	var resp json.RawMessage
+	if err := a.client.do(http.MethodPost, "/api/task", nil, task, &resp); err != nil {
+		return err
+	}
+	if a.json {
+		return a.printJSON(resp)
+	}
+
+	var created struct {
+		Id string `json:"id"`
+	}
+	if err := json.Unmarshal(resp, &created); err != nil {
+		return err
+	}
+	fmt.Fprintln(a.out, created.Id)
+	return nil
+}
+
+type tasksPage struct {
+	Tasks      []models.Task `json:"tasks"`
+	NextCursor string        `json:"next_cursor"`
+}
+
+func cmdList(a *app, args []string) error {
+	flags := newFlags("ls")
+	view := flags.String("view", "", "today, upcoming or overdue")
+	q := flags.String("q", "", "filter query, e.g. tag:home due<today+7")
+	sort := flags.String("sort", "", "date, -date, title, -title, created or -created")
+	limit := flags.Int("limit", 0, "page size")
+	all := flags.Bool("all", false, "fetch every page")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return usageError{"unexpected arguments"}
+	}
+
+	query := url.Values{}
+	for name, value := range map[string]string{"view": *view, "q": *q, "sort": *sort} {
+		if value != "" {
+			query.Set(name, value)
+		}
+	}
+	if *limit > 0 {
+		query.Set("limit", strconv.Itoa(*limit))
+	}
+	return a.listTasks(query, *all)
+}
+
+func cmdSearch(a *app, args []string) error {
+	flags := newFlags("search")
+	limit := flags.Int("limit", 0, "page size")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	text := strings.Join(flags.Args(), " ")
+	if text == "" {
+		return usageError{"search text is required"}
+	}
+
+	query := url.Values{"search": {text}}
+	if *limit > 0 {
+		query.Set("limit", strconv.Itoa(*limit))
+	}
+	return a.listTasks(query, false)
+}
+
+// listTasks prints a page of tasks, or every page when all is set.
+func (a *app) listTasks(query url.Values, all bool) error {
+	var tasks []models.Task
+	for {
+		var page tasksPage
+		if err := a.client.do(http.MethodGet, "/api/tasks", query, nil, &page); err != nil {
+			return err
+		}
+		tasks = append(tasks, page.Tasks...)
+		if !all || page.NextCursor == "" {
+			break
+		}
+		query.Set("cursor", page.NextCursor)
+	}
+
+	if a.json {
+		return a.printJSON(map[string][]models.Task{"tasks": tasks})
+	}
+	w := tabwriter.NewWriter(a.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tDATE\tTITLE\tREPEAT\tTAGS")
+	for _, task := range tasks {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", task.Id, task.Date, task.Title, task.Repeat, strings.Join(task.Tags, ","))
+	}
+	return w.Flush()
+}
+
+func cmdDone(a *app, args []string) error {
+	flags := newFlags("done")
+	note := flags.String("note", "", "note for the history")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	return a.eachID(flags.Args(), func(id string) error {
+		return a.client.do(http.MethodPost, "/api/task/done", url.Values{"id": {id}}, map[string]string{"note": *note}, nil)
+	})
+}
+
+func cmdRemove(a *app, args []string) error {
+	return a.eachID(args, func(id string) error {
+		return a.client.do(http.MethodDelete, "/api/task", url.Values{"id": {id}}, nil, nil)
+	})
+}
+
+// eachID runs fn for every id, stopping at the first failure.
+func (a *app) eachID(ids []string, fn func(id string) error) error {
+	if len(ids) == 0 {
+		return usageError{"task id is required"}
+	}
+	for _, id := range ids {
+		if err := fn(id); err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// cmdEdit sends only the fields given on the command line as a merge patch;
+// an empty -comment or -repeat clears the field.
+func cmdEdit(a *app, args []string) error {
+	flags := newFlags("edit")
+	flags.String("title", "", "title")
+	flags.String("date", "", "date as 20060102")
+	flags.String("repeat", "", "repeat rule")
+	flags.String("comment", "", "comment")
+	flags.String("tags", "", "comma-separated tags")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 1 {
+		return usageError{"exactly one task id is required"}
+	}
+
+	patch := make(map[string]any)
+	flags.Visit(func(f *flag.Flag) {
+		value := f.Value.String()
+		switch {
+		case f.Name == "tags":
+			patch["tags"] = splitTags(value)
+		case value == "" && (f.Name == "comment" || f.Name == "repeat"):
+			patch[f.Name] = nil
+		default:
+			patch[f.Name] = value
+		}
+	})
+	if len(patch) == 0 {
+		return usageError{"nothing to change"}
+	}
+
+	var resp json.RawMessage
+	if err := a.client.do(http.MethodPatch, "/api/task", url.Values{"id": {flags.Arg(0)}}, patch, &resp); err != nil {
+		return err
+	}
+	if a.json {
+		return a.printJSON(resp)
+	}
+	return nil
+}
+
+func cmdNext(a *app, args []string) error {
+	flags := newFlags("next")
+	now := flags.String("now", time.Now().Format(models.Layout), "date to count from")
+	if err := parse(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 2 {
+		return usageError{"date and repeat rule are required"}
+	}
+
+	query := url.Values{"now": {*now}, "date": {flags.Arg(0)}, "repeat": {flags.Arg(1)}}
+	var next string
+	if err := a.client.do(http.MethodGet, "/api/nextdate", query, nil, &next); err != nil {
+		return err
+	}
+	if a.json {
+		return a.printJSON(map[string]string{"date": next})
+	}
+	fmt.Fprintln(a.out, next)
+	return nil
+}
+
+func (a *app) printJSON(v any) error {
+	enc := json.NewEncoder(a.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,143 @@
+// Command todo is a command-line client for the scheduler API.
+//
+//	todo [-server URL] [-token TOKEN] [-json] <command> [flags] [args]
+//
+// The server defaults to TODO_SERVER or http://localhost:7540 and the token
+// to TODO_TOKEN. Without a token, TODO_PASSWORD (and TODO_LOGIN, admin by
+// default) is used to sign in.
+//
+// Exit codes: 0 on success, 1 on errors, 2 on invalid usage, 3 when a task
+// is not found and 4 when authentication fails.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+)
+
+const (
+	exitOK       = 0
+	exitError    = 1
+	exitUsage    = 2
+	exitNotFound = 3
+	exitAuth     = 4
+
+	defaultServer = "http://localhost:7540"
+)
+
+type app struct {
+	client *client
+	json   bool
+	out    io.Writer
+}
+
+type command struct {
+	usage string
+	run   func(a *app, args []string) error
+}
+
+var commands = map[string]command{
+	"add":    {"add [-date D] [-repeat R] [-comment C] [-tags a,b] <title>", cmdAdd},
+	"ls":     {"ls [-view today|upcoming|overdue] [-q query] [-sort S] [-limit N] [-all]", cmdList},
+	"search": {"search [-limit N] <text|02.01.2006>", cmdSearch},
+	"done":   {"done [-note N] <id>...", cmdDone},
+	"edit":   {"edit [-title T] [-date D] [-repeat R] [-comment C] [-tags a,b] <id>", cmdEdit},
+	"rm":     {"rm <id>...", cmdRemove},
+	"next":   {"next [-now D] <date> <repeat>", cmdNext},
+}
+
+// usageError is reported with exit code 2.
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("todo", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() { usage(stderr) }
+	server := flags.String("server", env("TODO_SERVER", defaultServer), "scheduler URL")
+	token := flags.String("token", os.Getenv("TODO_TOKEN"), "API token")
+	asJSON := flags.Bool("json", false, "print API responses as JSON")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	if flags.NArg() == 0 {
+		usage(stderr)
+		return exitUsage
+	}
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "todo: unknown command %q\n", flags.Arg(0))
+		usage(stderr)
+		return exitUsage
+	}
+
+	a := &app{client: newClient(*server, *token), json: *asJSON, out: stdout}
+	if password := os.Getenv("TODO_PASSWORD"); *token == "" && password != "" {
+		if err := a.client.signIn(os.Getenv("TODO_LOGIN"), password); err != nil {
+			fmt.Fprintf(stderr, "todo: sign in: %v\n", err)
+			return exitCode(err)
+		}
+	}
+
+	if err := cmd.run(a, flags.Args()[1:]); err != nil {
+		fmt.Fprintf(stderr, "todo %s: %v\n", flags.Arg(0), err)
+		var uerr usageError
+		if errors.As(err, &uerr) {
+			fmt.Fprintf(stderr, "usage: todo %s\n", cmd.usage)
+		}
+		return exitCode(err)
+	}
+	return exitOK
+}
+
+func exitCode(err error) int {
+	var (
+		uerr usageError
+		aerr *apiError
+	)
+	switch {
+	case errors.As(err, &uerr):
+		return exitUsage
+	case errors.As(err, &aerr) && aerr.status == http.StatusNotFound:
+		return exitNotFound
+	case errors.As(err, &aerr) && aerr.status == http.StatusUnauthorized:
+		return exitAuth
+	default:
+		return exitError
+	}
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: todo [-server URL] [-token TOKEN] [-json] <command> [flags] [args]")
+	fmt.Fprintln(w, "\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\n", commands[name].usage)
+	}
+}
+
+func env(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer is a scheduler API with two tasks over two pages. It requires
+// the bearer token "secret", which /api/signin issues for the password
+// "pass", and records the PATCH bodies it receives.
+func fakeServer(t *testing.T) (string, *[]map[string]any) {
+	var patches []map[string]any
+	writeJSON := func(w http.ResponseWriter, status int, v any) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(v)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/signin", func(w http.ResponseWriter, r *http.Request) {
+		var creds map[string]string
+		json.NewDecoder(r.Body).Decode(&creds)
+		if creds["password"] != "pass" {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "wrong login or password"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"token": "secret"})
+	})
+	mux.HandleFunc("GET /api/tasks", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("cursor") == "" {
+			writeJSON(w, http.StatusOK, map[string]any{
+				"tasks":       []map[string]any{{"id": "1", "date": "20240310", "title": "Купить молоко", "repeat": "", "tags": []string{"дом"}}},
+				"next_cursor": "c1",
+			})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{
+			"tasks": []map[string]any{{"id": "2", "date": "20240311", "title": "Отчёт", "repeat": "d 7"}},
+		})
+	})
+	mux.HandleFunc("POST /api/task", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"id": "3"})
+	})
+	mux.HandleFunc("PATCH /api/task", func(w http.ResponseWriter, r *http.Request) {
+		var patch map[string]any
+		json.NewDecoder(r.Body).Decode(&patch)
+		patches = append(patches, patch)
+		writeJSON(w, http.StatusOK, map[string]string{"id": r.URL.Query().Get("id")})
+	})
+	mux.HandleFunc("POST /api/task/done", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("id") != "1" {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, struct{}{})
+	})
+	mux.HandleFunc("DELETE /api/task", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "database is locked"})
+	})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/signin" && r.Header.Get("Authorization") != "Bearer secret" {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL, &patches
+}
+
+func runTodo(t *testing.T, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRun(t *testing.T) {
+	t.Setenv("TODO_TOKEN", "")
+	t.Setenv("TODO_PASSWORD", "")
+	server, patches := fakeServer(t)
+	auth := []string{"-server", server, "-token", "secret"}
+
+	code, out, _ := runTodo(t, append(auth, "ls", "-all")...)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "ID  DATE      TITLE          REPEAT  TAGS\n"+
+		"1   20240310  Купить молоко          дом\n"+
+		"2   20240311  Отчёт          d 7     \n", out)
+
+	code, out, _ = runTodo(t, append(auth, "-json", "ls")...)
+	assert.Equal(t, exitOK, code)
+	var list map[string][]map[string]any
+	require.NoError(t, json.Unmarshal([]byte(out), &list))
+	require.Len(t, list["tasks"], 1)
+	assert.Equal(t, "Купить молоко", list["tasks"][0]["title"])
+
+	code, out, _ = runTodo(t, append(auth, "add", "-tags", "a,b", "Новая", "задача")...)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, "3\n", out)
+
+	code, _, _ = runTodo(t, append(auth, "edit", "-comment", "", "-title", "Другое", "1")...)
+	assert.Equal(t, exitOK, code)
+	assert.Equal(t, []map[string]any{{"comment": nil, "title": "Другое"}}, *patches)
+
+	code, _, _ = runTodo(t, append(auth, "done", "1")...)
+	assert.Equal(t, exitOK, code)
+}
+
+func TestRunExitCodes(t *testing.T) {
+	t.Setenv("TODO_TOKEN", "")
+	t.Setenv("TODO_PASSWORD", "")
+	server, _ := fakeServer(t)
+	auth := []string{"-server", server, "-token", "secret"}
+
+	for _, args := range [][]string{
+		{},
+		{"-nope"},
+		{"frobnicate"},
+		append(auth, "add"),
+		append(auth, "ls", "extra"),
+		append(auth, "ls", "-limit", "x"),
+		append(auth, "edit", "1"),
+		append(auth, "next", "20240101"),
+	} {
+		code, _, stderr := runTodo(t, args...)
+		assert.Equal(t, exitUsage, code, args)
+		assert.Contains(t, stderr, "usage: todo", args)
+	}
+
+	code, _, stderr := runTodo(t, append(auth, "done", "1", "404", "2")...)
+	assert.Equal(t, exitNotFound, code)
+	assert.Equal(t, "todo done: 404: task not found\n", stderr)
+
+	code, _, stderr = runTodo(t, "-server", server, "-token", "stale", "ls")
+	assert.Equal(t, exitAuth, code)
+	assert.Contains(t, stderr, "authentication required")
+
+	t.Setenv("TODO_PASSWORD", "wrong")
+	code, _, stderr = runTodo(t, "-server", server, "ls")
+	assert.Equal(t, exitAuth, code)
+	assert.Equal(t, "todo: sign in: wrong login or password\n", stderr)
+
+	t.Setenv("TODO_PASSWORD", "pass")
+	code, _, _ = runTodo(t, "-server", server, "ls")
+	assert.Equal(t, exitOK, code)
+
+	code, _, stderr = runTodo(t, append(auth, "rm", "1")...)
+	assert.Equal(t, exitError, code)
+	assert.Equal(t, "todo rm: 1: database is locked\n", stderr)
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	passwordIterations = 210000
+	passwordSaltSize   = 16
+	passwordKeySize    = 32
+)
+
+// HashPassword derives a PBKDF2-HMAC-SHA256 hash of password with a random
+// salt, encoded as pbkdf2-sha256$iterations$salt$key.
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, passwordSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	key := pbkdf2.Key([]byte(password), salt, passwordIterations, passwordKeySize, sha256.New)
+
+	enc := base64.RawStdEncoding
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", passwordIterations,
+		enc.EncodeToString(salt), enc.EncodeToString(key)), nil
+}
+
+// CheckPassword reports whether password matches a hash from HashPassword.
+func CheckPassword(hash, password string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations < 1 {
+		return false
+	}
+
+	enc := base64.RawStdEncoding
+	salt, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+	key, err := enc.DecodeString(parts[3])
+	if err != nil || len(key) != passwordKeySize {
+		return false
+	}
+	return subtle.ConstantTimeCompare(key, pbkdf2.Key([]byte(password), salt, iterations, passwordKeySize, sha256.New)) == 1
+}
@@ -0,0 +1,18 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPassword(t *testing.T) {
+	hash, err := HashPassword("secret")
+	require.NoError(t, err)
+
+	assert.True(t, CheckPassword(hash, "secret"))
+	assert.False(t, CheckPassword(hash, "Secret"))
+	assert.False(t, CheckPassword("", "secret"))
+	assert.False(t, CheckPassword("bcrypt$1$c2FsdA$a2V5", "secret"))
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+// Tokens issues and verifies HS256 JSON Web Tokens naming a user.
+type Tokens struct {
+	secret []byte
+	ttl    time.Duration
+}
+
+func NewTokens(secret []byte, ttl time.Duration) Tokens {
+	return Tokens{secret: secret, ttl: ttl}
+}
+
+func (t Tokens) Issue(user string, now time.Time) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   user,
+		ExpiresAt: jwt.NewNumericDate(now.Add(t.ttl)),
+	})
+	return token.SignedString(t.secret)
+}
+
+// Verify returns the user named by a valid, unexpired token.
+func (t Tokens) Verify(token string, now time.Time) (string, error) {
+	var c jwt.RegisteredClaims
+	_, err := jwt.ParseWithClaims(token, &c, func(*jwt.Token) (any, error) {
+		return t.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(func() time.Time { return now }),
+	)
+	if err != nil || c.Subject == "" {
+		return "", ErrInvalidToken
+	}
+	return c.Subject, nil
+}
+
+type userKey struct{}
+
+func WithUser(ctx context.Context, user string) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// User returns the signed-in user, or "" when authentication is off.
+func User(ctx context.Context) string {
+	user, _ := ctx.Value(userKey{}).(string)
+	return user
+}
+
+// DefaultUser is the login assumed when a sign-in names none, which is what
+// the web UI sends, and the user that TODO_PASSWORD sets up.
+const DefaultUser = "admin"
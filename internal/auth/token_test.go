@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokens(t *testing.T) {
+	now := time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC)
+	tokens := NewTokens([]byte("secret"), time.Hour)
+
+	token, err := tokens.Issue("admin", now)
+	require.NoError(t, err)
+
+	user, err := tokens.Verify(token, now.Add(59*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, "admin", user)
+
+	_, err = tokens.Verify(token, now.Add(time.Hour))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = NewTokens([]byte("other"), time.Hour).Verify(token, now)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	for _, bad := range []string{"", token + "x", token[:len(token)-1], "a.b.c"} {
+		_, err = tokens.Verify(bad, now)
+		assert.ErrorIs(t, err, ErrInvalidToken, bad)
+	}
+}
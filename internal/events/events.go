@@ -0,0 +1,66 @@
+package events
+
+import (
+	"sync"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+const (
+	TaskCreated   = "task.created"
+	TaskUpdated   = "task.updated"
+	TaskCompleted = "task.completed"
+	TaskDeleted   = "task.deleted"
+	TaskPurged    = "task.purged"
+)
+
+var Types = []string{TaskCreated, TaskUpdated, TaskCompleted, TaskDeleted, TaskPurged}
+
+// Actions names the audit log action recorded for each event type.
+var Actions = map[string]string{
+	TaskCreated:   "create",
+	TaskUpdated:   "update",
+	TaskCompleted: "done",
+	TaskDeleted:   "delete",
+	TaskPurged:    "purge",
+}
+
+// Event describes a change to a task. Time is RFC 3339. User is who made the
+// change when authentication is on.
+//
+// Task is the task as created or updated, or as it was before it was
+// completed, deleted or purged from the trash. Previous is the task before
+// an update, and Next is the date a completed recurring task moved to.
+type Event struct {
+	Type     string       `json:"type"`
+	Time     string       `json:"time"`
+	User     string       `json:"user,omitempty"`
+	Task     models.Task  `json:"task"`
+	Previous *models.Task `json:"previous,omitempty"`
+	Next     string       `json:"next,omitempty"`
+}
+
+// Bus fans events published by the write handlers out to subscribers.
+// Subscribers run synchronously in Publish, so they must not block.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers []func(Event)
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+func (b *Bus) Subscribe(fn func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, fn)
+}
+
+func (b *Bus) Publish(e Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, fn := range b.subscribers {
+		fn(e)
+	}
+}
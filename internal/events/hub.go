@@ -0,0 +1,137 @@
+package events
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ID numbers an event in publishing order within a hub's epoch, which is
+// chosen when the hub is created so that ids from before a restart are never
+// taken for current ones. It is used as the SSE event id, epoch-seq.
+type ID struct {
+	Epoch string
+	Seq   uint64
+}
+
+func (id ID) String() string {
+	return id.Epoch + "-" + strconv.FormatUint(id.Seq, 10)
+}
+
+var ErrInvalidID = errors.New("invalid event id")
+
+// ParseID parses an ID from String. A bare number, as sent by clients from
+// before epochs, has an empty epoch and so never matches a hub.
+func ParseID(s string) (ID, error) {
+	epoch, seq, found := strings.Cut(s, "-")
+	if !found {
+		epoch, seq = "", s
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	if err != nil {
+		return ID{}, ErrInvalidID
+	}
+	return ID{Epoch: epoch, Seq: n}, nil
+}
+
+// Entry is a published event with its id.
+type Entry struct {
+	ID    ID
+	Event Event
+}
+
+// Hub keeps the most recent events for resuming and fans new ones out to
+// live subscribers. A subscriber that falls behind by more than its buffer
+// is dropped and is expected to reconnect with its last seen id.
+type Hub struct {
+	mu      sync.Mutex
+	size    int
+	epoch   string
+	lastID  uint64
+	recent  []Entry
+	clients map[chan Entry]struct{}
+}
+
+const clientBuffer = 64
+
+// Reset tells a resuming subscriber that events were lost.
+const Reset = "reset"
+
+func NewHub(size int) *Hub {
+	return &Hub{
+		size:    size,
+		epoch:   strconv.FormatInt(time.Now().UnixNano(), 36),
+		clients: make(map[chan Entry]struct{}),
+	}
+}
+
+func (h *Hub) Publish(e Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	entry := Entry{ID: ID{Epoch: h.epoch, Seq: h.lastID}, Event: e}
+	h.recent = append(h.recent, entry)
+	if len(h.recent) > h.size {
+		h.recent = h.recent[len(h.recent)-h.size:]
+	}
+
+	for ch := range h.clients {
+		select {
+		case ch <- entry:
+		default:
+			delete(h.clients, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe registers a subscriber for events published from now on. The
+// returned channel is closed when the subscriber is dropped or cancel is
+// called.
+func (h *Hub) Subscribe() (<-chan Entry, func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.subscribe()
+}
+
+// Resume is Subscribe for a subscriber that has seen every event up to
+// lastID; it also returns the events published since then. When those are no
+// longer all kept, or lastID is from another epoch, e.g. before a restart,
+// missed is a single Reset entry instead and the subscriber should reload
+// its state.
+func (h *Hub) Resume(lastID ID) (missed []Entry, ch <-chan Entry, cancel func()) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	complete := false
+	if lastID.Epoch == h.epoch && lastID.Seq <= h.lastID {
+		for _, entry := range h.recent {
+			if entry.ID.Seq > lastID.Seq {
+				missed = append(missed, entry)
+			}
+		}
+		complete = len(missed) == 0 || missed[0].ID.Seq == lastID.Seq+1
+	}
+	if !complete {
+		missed = []Entry{{ID: ID{Epoch: h.epoch, Seq: h.lastID}, Event: Event{Type: Reset}}}
+	}
+
+	ch, cancel = h.subscribe()
+	return missed, ch, cancel
+}
+
+func (h *Hub) subscribe() (<-chan Entry, func()) {
+	c := make(chan Entry, clientBuffer)
+	h.clients[c] = struct{}{}
+	return c, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		if _, ok := h.clients[c]; ok {
+			delete(h.clients, c)
+			close(c)
+		}
+	}
+}
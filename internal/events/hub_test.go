@@ -0,0 +1,89 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/paran0iaa/TODO/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func publishN(h *Hub, n int) {
+	for i := 0; i < n; i++ {
+		h.Publish(Event{Type: TaskCreated, Task: models.Task{Title: "t"}})
+	}
+}
+
+func TestHubResume(t *testing.T) {
+	h := NewHub(3)
+	publishN(h, 4)
+
+	missed, _, cancel := h.Resume(ID{h.epoch, 2})
+	defer cancel()
+	assert.Len(t, missed, 2)
+	assert.Equal(t, ID{h.epoch, 3}, missed[0].ID)
+	assert.Equal(t, ID{h.epoch, 4}, missed[1].ID)
+
+	missed, _, cancel = h.Resume(ID{h.epoch, 4})
+	defer cancel()
+	assert.Empty(t, missed)
+}
+
+func TestHubResumeReset(t *testing.T) {
+	h := NewHub(3)
+	publishN(h, 5)
+
+	reset := []Entry{{ID: ID{h.epoch, 5}, Event: Event{Type: Reset}}}
+
+	// Event 2 has fallen out of the history.
+	missed, _, cancel := h.Resume(ID{h.epoch, 1})
+	defer cancel()
+	assert.Equal(t, reset, missed)
+
+	missed, _, cancel = h.Resume(ID{h.epoch, 42})
+	defer cancel()
+	assert.Equal(t, reset, missed)
+
+	// After a restart the new hub has reached a lower id than the client's,
+	// and then published past it again.
+	before := h
+	h = NewHub(3)
+	publishN(h, 7)
+	assert.NotEqual(t, before.epoch, h.epoch)
+	missed, _, cancel = h.Resume(ID{before.epoch, 5})
+	defer cancel()
+	assert.Equal(t, []Entry{{ID: ID{h.epoch, 7}, Event: Event{Type: Reset}}}, missed)
+
+	// A bare number from a client of an older server.
+	missed, _, cancel = h.Resume(ID{Seq: 6})
+	defer cancel()
+	assert.Equal(t, Reset, missed[0].Event.Type)
+}
+
+func TestParseID(t *testing.T) {
+	id := ID{Epoch: "lx3k9a", Seq: 17}
+	parsed, err := ParseID(id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, id, parsed)
+
+	parsed, err = ParseID("17")
+	assert.NoError(t, err)
+	assert.Equal(t, ID{Seq: 17}, parsed)
+
+	for _, bad := range []string{"", "lx3k9a-", "lx3k9a-x", "lx3k9a-1-2"} {
+		_, err = ParseID(bad)
+		assert.ErrorIs(t, err, ErrInvalidID, bad)
+	}
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	h := NewHub(10)
+	ch, cancel := h.Subscribe()
+	defer cancel()
+
+	publishN(h, clientBuffer+1)
+	n := 0
+	for range ch {
+		n++
+	}
+	assert.Equal(t, clientBuffer, n)
+}
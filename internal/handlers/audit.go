@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/events"
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+const (
+	auditLimit    = 50
+	maxAuditLimit = 500
+)
+
+// GetAudit lists the audit log, newest first, filtered by task_id, user,
+// action and the since and until dates (20060102, both inclusive).
+func GetAudit(store db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter := db.AuditFilter{
+			TaskId: r.URL.Query().Get("task_id"),
+			User:   r.URL.Query().Get("user"),
+			Action: r.URL.Query().Get("action"),
+			Limit:  auditLimit,
+		}
+		if filter.Action != "" && !validAction(filter.Action) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid action: %s", filter.Action))
+			return
+		}
+
+		for _, bound := range []struct {
+			param string
+			days  int
+			dest  *string
+		}{
+			{"since", 0, &filter.Since},
+			{"until", 1, &filter.Until},
+		} {
+			value := r.URL.Query().Get(bound.param)
+			if value == "" {
+				continue
+			}
+			date, err := time.ParseInLocation(models.Layout, value, time.Local)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("invalid %s: %s", bound.param, value))
+				return
+			}
+			*bound.dest = date.AddDate(0, 0, bound.days).UTC().Format(time.RFC3339)
+		}
+
+		if value := r.URL.Query().Get("limit"); value != "" {
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit < 1 || limit > maxAuditLimit {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit: %s", value))
+				return
+			}
+			filter.Limit = limit
+		}
+
+		entries, err := store.AuditLog(filter)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string][]models.AuditEntry{"entries": entries})
+	}
+}
+
+func validAction(action string) bool {
+	for _, a := range events.Actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/auth"
+	"github.com/paran0iaa/TODO/internal/services"
+)
+
+func SignIn(store db.Store, tokens auth.Tokens) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var creds struct {
+			Login    string `json:"login"`
+			Password string `json:"password"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid sign-in json"))
+			return
+		}
+		if creds.Login == "" {
+			creds.Login = auth.DefaultUser
+		}
+
+		hash, err := store.PasswordHash(creds.Login)
+		if err != nil && !errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if err != nil || !auth.CheckPassword(hash, creds.Password) {
+			writeError(w, http.StatusUnauthorized, errors.New("wrong login or password"))
+			return
+		}
+
+		token, err := tokens.Issue(creds.Login, services.Now())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"token": token})
+	}
+}
+
+// RequireAuth rejects requests without a valid token once any user exists.
+// The token is taken from an Authorization: Bearer header or, without one,
+// from the token cookie set by the web UI.
+func RequireAuth(store db.Store, tokens auth.Tokens) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			enabled, err := store.HasUsers()
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			if !enabled {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, bearer := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !bearer {
+				token = ""
+				if cookie, err := r.Cookie("token"); err == nil {
+					token = cookie.Value
+				}
+			}
+
+			user, err := tokens.Verify(token, services.Now())
+			if err != nil {
+				writeError(w, http.StatusUnauthorized, errors.New("authentication required"))
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), user)))
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/events"
+	"github.com/paran0iaa/TODO/internal/models"
+	"github.com/paran0iaa/TODO/internal/services"
+)
+
+const bulkLimit = 500
+
+// bulkOp is one operation of a bulk request. create and update take task;
+// done, delete and reschedule take id, done an optional note and reschedule
+// the number of days to move the task by. IfMatch is the ETag the task must
+// still have, as the If-Match header of a single change.
+type bulkOp struct {
+	Op      string   `json:"op"`
+	Id      string   `json:"id"`
+	Task    taskBody `json:"task"`
+	Note    string   `json:"note"`
+	Days    int      `json:"days"`
+	IfMatch string   `json:"if_match"`
+}
+
+type bulkResult struct {
+	Id    string `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// errBulkOp marks an operation that failed because of its input rather than
+// the database.
+var errBulkOp = errors.New("invalid operation")
+
+// BulkTasks applies a list of operations in one transaction. If any of them
+// fails nothing is applied, and the results end with the failed operation.
+func BulkTasks(store db.Store, bus *events.Bus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Operations []bulkOp `json:"operations"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid bulk json"))
+			return
+		}
+		if len(req.Operations) == 0 || len(req.Operations) > bulkLimit {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("expected 1 to %d operations", bulkLimit))
+			return
+		}
+
+		var (
+			results   []bulkResult
+			published []events.Event
+		)
+		err := store.Batch(func(b db.Batch) error {
+			for i, op := range req.Operations {
+				event, err := applyBulkOp(b, op, newEvent(r, "", models.Task{}))
+				if err != nil {
+					results = append(results, bulkResult{Id: op.Id, Error: err.Error()})
+					return fmt.Errorf("operation %d: %w", i, err)
+				}
+				if err = record(b, event); err != nil {
+					return err
+				}
+				results = append(results, bulkResult{Id: event.Task.Id})
+				published = append(published, event)
+			}
+			return nil
+		})
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, errBulkOp):
+				status = http.StatusBadRequest
+			case errors.Is(err, db.ErrConflict):
+				status = http.StatusPreconditionFailed
+			}
+			writeJSON(w, status, map[string]any{"error": err.Error(), "results": results})
+			return
+		}
+
+		for _, event := range published {
+			bus.Publish(event)
+		}
+		writeJSON(w, http.StatusOK, map[string][]bulkResult{"results": results})
+	}
+}
+
+// applyBulkOp applies op and fills in event to describe it.
+func applyBulkOp(b db.Batch, op bulkOp, event events.Event) (events.Event, error) {
+	switch op.Op {
+	case "create":
+		task := op.Task.Task
+		if err := services.CheckTask(&task, services.Now()); err != nil {
+			return event, fmt.Errorf("%w: %w", errBulkOp, err)
+		}
+		id, err := b.AddTask(task)
+		if err != nil {
+			return event, err
+		}
+		task.Id = id
+		event.Type, event.Task = events.TaskCreated, task
+		return event, nil
+
+	case "update":
+		id := op.Task.Id
+		if id == "" {
+			id = op.Id
+		}
+		if id == "" {
+			return event, fmt.Errorf("%w: task id is required", errBulkOp)
+		}
+		op.Id = id
+		previous, err := bulkTask(b, op)
+		if err != nil {
+			return event, err
+		}
+		task := op.Task.keep(previous)
+		task.Id = id
+		if err := services.CheckTask(&task, services.Now()); err != nil {
+			return event, fmt.Errorf("%w: %w", errBulkOp, err)
+		}
+		event.Type, event.Task, event.Previous = events.TaskUpdated, task, &previous
+		return event, b.UpdateTask(task)
+
+	case "done":
+		task, err := bulkTask(b, op)
+		if err != nil {
+			return event, err
+		}
+		completion, next, err := complete(task, op.Note)
+		if err != nil {
+			return event, fmt.Errorf("%w: %w", errBulkOp, err)
+		}
+		event.Type, event.Task, event.Next = events.TaskCompleted, task, next.Date
+		return event, b.CompleteTask(completion, next)
+
+	case "delete":
+		task, err := bulkTask(b, op)
+		if err != nil {
+			return event, err
+		}
+		event.Type, event.Task = events.TaskDeleted, task
+		return event, b.DeleteTask(task.Id, 0, event.Time)
+
+	case "reschedule":
+		if op.Days == 0 {
+			return event, fmt.Errorf("%w: days is required", errBulkOp)
+		}
+		task, err := bulkTask(b, op)
+		if err != nil {
+			return event, err
+		}
+		date, err := time.Parse(models.Layout, task.Date)
+		if err != nil {
+			return event, fmt.Errorf("invalid task date: %w", err)
+		}
+		previous := task
+		task.Date = date.AddDate(0, 0, op.Days).Format(models.Layout)
+		// Like in PUT, a date moved into the past becomes today or the next
+		// occurrence.
+		if err = services.CheckTask(&task, services.Now()); err != nil {
+			return event, fmt.Errorf("%w: %w", errBulkOp, err)
+		}
+		event.Type, event.Task, event.Previous = events.TaskUpdated, task, &previous
+		return event, b.UpdateTask(task)
+
+	default:
+		return event, fmt.Errorf("%w: unknown op %q", errBulkOp, op.Op)
+	}
+}
+
+func bulkTask(b db.Batch, op bulkOp) (models.Task, error) {
+	if op.Id == "" {
+		return models.Task{}, fmt.Errorf("%w: task id is required", errBulkOp)
+	}
+	task, err := b.GetTask(op.Id)
+	if err != nil {
+		return models.Task{}, notFound(err)
+	}
+	// The whole batch runs in one transaction, so the task can't change
+	// between this check and the operation.
+	if version := matchVersion(op.IfMatch); version != 0 && version != task.Version {
+		return models.Task{}, fmt.Errorf("%w: task has been changed since it was read", db.ErrConflict)
+	}
+	return task, nil
+}
+
+// notFound turns a missing task into an operation error.
+func notFound(err error) error {
+	if errors.Is(err, db.ErrNotFound) {
+		return fmt.Errorf("%w: task not found", errBulkOp)
+	}
+	return err
+}
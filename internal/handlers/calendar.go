@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/models"
+	"github.com/paran0iaa/TODO/internal/services"
+)
+
+const (
+	maxCalendarDays = 366
+	// maxCalendarYears bounds how far ahead a calendar can start, since the
+	// series of every task is stepped through up to from.
+	maxCalendarYears = 5
+)
+
+// GetCalendar returns every occurrence of every task between from and to,
+// expanding recurring tasks. It takes the q and filter parameters of
+// /api/tasks to narrow the tasks down.
+func GetCalendar(store db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		from, to, err := calendarRange(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		entries, ok := calendarEntries(w, r, store, from, to)
+		if !ok {
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string][]models.CalendarEntry{"occurrences": entries})
+	}
+}
+
+// calendarEntries expands the tasks the request selects between from and to
+// and writes an error response when it can't.
+func calendarEntries(w http.ResponseWriter, r *http.Request, store db.Store, from, to string) ([]models.CalendarEntry, bool) {
+	filter := db.TaskFilter{Limit: -1}
+	if err := applyQuery(&filter, r, store); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return nil, false
+	}
+
+	tasks, err := store.Tasks(filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+	entries, err := services.Calendar(tasks, from, to)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return nil, false
+	}
+	return entries, true
+}
+
+// calendarRange reads the from and to dates of a calendar request.
+func calendarRange(r *http.Request) (string, string, error) {
+	var dates [2]time.Time
+	for i, param := range []string{"from", "to"} {
+		value := r.URL.Query().Get(param)
+		if value == "" {
+			return "", "", fmt.Errorf("%s is required", param)
+		}
+		date, err := time.Parse(models.Layout, value)
+		if err != nil {
+			return "", "", fmt.Errorf("invalid %s: %s", param, value)
+		}
+		dates[i] = date
+	}
+
+	from, to := dates[0], dates[1]
+	if to.Before(from) {
+		return "", "", errors.New("to is before from")
+	}
+	if to.After(from.AddDate(0, 0, maxCalendarDays-1)) {
+		return "", "", fmt.Errorf("the range is longer than %d days", maxCalendarDays)
+	}
+	horizon := services.Today().AddDate(maxCalendarYears, 0, 0).Format(models.Layout)
+	if from.Format(models.Layout) > horizon {
+		return "", "", fmt.Errorf("from is more than %d years ahead", maxCalendarYears)
+	}
+	return from.Format(models.Layout), to.Format(models.Layout), nil
+}
+
+// GetForecast reports the number of tasks and estimated minutes of each day
+// of the calendar range, flags the days over capacity and lists the tasks
+// whose times overlap. The capacity and max_tasks parameters override the
+// configured capacity.
+func GetForecast(store db.Store, capacity services.Capacity) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		from, to, err := calendarRange(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		capacity := capacity
+		for _, limit := range []struct {
+			param string
+			dest  *int
+		}{
+			{"capacity", &capacity.Minutes},
+			{"max_tasks", &capacity.Tasks},
+		} {
+			value := r.URL.Query().Get(limit.param)
+			if value == "" {
+				continue
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("invalid %s: %s", limit.param, value))
+				return
+			}
+			*limit.dest = n
+		}
+
+		// The tasks of the day before from may run past its midnight.
+		start, _ := time.Parse(models.Layout, from)
+		entries, ok := calendarEntries(w, r, store, start.AddDate(0, 0, -1).Format(models.Layout), to)
+		if !ok {
+			return
+		}
+		days, err := services.Forecast(entries, from, to, capacity)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string][]models.ForecastDay{"days": days})
+	}
+}
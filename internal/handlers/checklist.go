@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/events"
+)
+
+func GetChecklist(store db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		task, ok := taskFromQuery(w, r, store)
+		if !ok {
+			return
+		}
+
+		items, err := store.Checklist(task.Id)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"items": items})
+	}
+}
+
+func AddChecklistItem(store db.Store, bus *events.Bus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		task, ok := taskFromQuery(w, r, store)
+		if !ok {
+			return
+		}
+
+		var item struct {
+			Title string `json:"title"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid checklist item json"))
+			return
+		}
+		if item.Title == "" {
+			writeError(w, http.StatusBadRequest, errors.New("checklist item title is required"))
+			return
+		}
+
+		var id string
+		err := commit(store, bus, func(b db.Batch) (events.Event, error) {
+			return touch(r, b, task.Id, func() error {
+				var err error
+				id, err = b.AddChecklistItem(task.Id, item.Title)
+				return err
+			})
+		})
+		if err != nil {
+			writeTaskError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, map[string]string{"id": id})
+	}
+}
+
+func ChecklistItemDone(store db.Store, bus *events.Bus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// done=false reopens an item that was ticked by mistake.
+		done := r.URL.Query().Get("done") != "false"
+		changeChecklistItem(w, r, store, bus, func(b db.Batch, id string) error {
+			return b.SetChecklistItemDone(id, done)
+		})
+	}
+}
+
+func DeleteChecklistItem(store db.Store, bus *events.Bus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		changeChecklistItem(w, r, store, bus, db.Batch.DeleteChecklistItem)
+	}
+}
+
+func changeChecklistItem(w http.ResponseWriter, r *http.Request, store db.Store, bus *events.Bus,
+	change func(b db.Batch, id string) error) {
+	id := r.URL.Query().Get("id")
+	err := commit(store, bus, func(b db.Batch) (events.Event, error) {
+		taskID, err := b.ChecklistItemTask(id)
+		if err != nil {
+			return events.Event{}, err
+		}
+		return touch(r, b, taskID, func() error {
+			return change(b, id)
+		})
+	})
+	if errors.Is(err, db.ErrNotFound) {
+		writeError(w, http.StatusNotFound, errors.New("checklist item not found"))
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, struct{}{})
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/events"
+)
+
+func GetDependencies(store db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		task, ok := taskFromQuery(w, r, store)
+		if !ok {
+			return
+		}
+
+		tasks, err := store.Dependencies(task.Id)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"blocked": task.Blocked, "dependencies": tasks})
+	}
+}
+
+func AddDependency(store db.Store, bus *events.Bus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var dep struct {
+			DependsOn string `json:"depends_on"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&dep); err != nil || dep.DependsOn == "" {
+			writeError(w, http.StatusBadRequest, errors.New("depends_on is required"))
+			return
+		}
+
+		id := r.URL.Query().Get("id")
+		err := commit(store, bus, func(b db.Batch) (events.Event, error) {
+			return touch(r, b, id, func() error {
+				return b.AddDependency(id, dep.DependsOn)
+			})
+		})
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			writeError(w, http.StatusNotFound, errors.New("task not found"))
+		case errors.Is(err, db.ErrDependencyCycle):
+			writeError(w, http.StatusConflict, err)
+		case err != nil:
+			writeError(w, http.StatusInternalServerError, err)
+		default:
+			writeJSON(w, http.StatusOK, struct{}{})
+		}
+	}
+}
+
+func DeleteDependency(store db.Store, bus *events.Bus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		err := commit(store, bus, func(b db.Batch) (events.Event, error) {
+			return touch(r, b, id, func() error {
+				return b.DeleteDependency(id, r.URL.Query().Get("depends_on"))
+			})
+		})
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("dependency not found"))
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, struct{}{})
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/paran0iaa/TODO/internal/events"
+)
+
+const heartbeatInterval = 15 * time.Second
+
+// Events streams task changes as server-sent events. A client reconnecting
+// with Last-Event-ID first receives what it missed, or a reset event when
+// that is no longer available.
+func Events(hub *events.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			writeError(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+			return
+		}
+
+		var (
+			missed []events.Entry
+			ch     <-chan events.Entry
+			cancel func()
+		)
+		if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+			id, err := events.ParseID(lastID)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("invalid Last-Event-ID: %s", lastID))
+				return
+			}
+			missed, ch, cancel = hub.Resume(id)
+		} else {
+			ch, cancel = hub.Subscribe()
+		}
+		defer cancel()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+
+		for _, entry := range missed {
+			if err := writeEvent(w, entry); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+
+		heartbeat := time.NewTicker(heartbeatInterval)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case entry, ok := <-ch:
+				if !ok {
+					return
+				}
+				if err := writeEvent(w, entry); err != nil {
+					return
+				}
+			case <-heartbeat.C:
+				if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+					return
+				}
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func writeEvent(w http.ResponseWriter, entry events.Entry) error {
+	var payload any = entry.Event
+	if entry.Event.Type == events.Reset {
+		payload = map[string]string{"type": events.Reset}
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", entry.ID, entry.Event.Type, data)
+	return err
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/events"
+	"github.com/paran0iaa/TODO/internal/models"
+	"github.com/paran0iaa/TODO/internal/services"
+)
+
+const (
+	maxOccurrences = 366
+	// maxExceptionDays bounds how far ahead an occurrence can be skipped or
+	// moved, which also bounds the work of checking it.
+	maxExceptionDays = 366
+)
+
+func GetExceptions(store db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		task, ok := taskFromQuery(w, r, store)
+		if !ok {
+			return
+		}
+		exceptions := task.Exceptions
+		if exceptions == nil {
+			exceptions = []models.Exception{}
+		}
+		writeJSON(w, http.StatusOK, map[string][]models.Exception{"exceptions": exceptions})
+	}
+}
+
+// AddException skips or moves one occurrence of a recurring task without
+// changing its rule. An exception for the current occurrence takes effect
+// at once: the task moves to the next occurrence or to the new date.
+func AddException(store db.Store, bus *events.Bus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		task, ok := taskFromQuery(w, r, store)
+		if !ok {
+			return
+		}
+		if task.Repeat == "" {
+			writeError(w, http.StatusBadRequest, errors.New("only recurring tasks have exceptions"))
+			return
+		}
+
+		var e models.Exception
+		if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid exception json"))
+			return
+		}
+		if err := checkException(task, e); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		var next *models.Occurrence
+		if e.Date == services.CurrentOccurrence(task).Scheduled {
+			occurrence := models.Occurrence{Date: e.MovedTo, Scheduled: e.Date}
+			if e.MovedTo == "" {
+				var err error
+				occurrence, err = services.NextOccurrence(e.Date, e.Date, task.Repeat, task.Exceptions)
+				if err != nil {
+					writeError(w, http.StatusInternalServerError, err)
+					return
+				}
+				if occurrence.Date == "" {
+					writeError(w, http.StatusBadRequest, errors.New("the task has no further occurrences"))
+					return
+				}
+			}
+			next = &occurrence
+		}
+
+		var updated models.Task
+		err := commit(store, bus, func(b db.Batch) (events.Event, error) {
+			if err := b.SaveException(task.Id, e, next); err != nil {
+				return events.Event{}, err
+			}
+			var err error
+			updated, err = b.GetTask(task.Id)
+			event := newEvent(r, events.TaskUpdated, updated)
+			event.Previous = &task
+			return event, err
+		})
+		if err != nil {
+			writeTaskError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, updated)
+	}
+}
+
+func checkException(task models.Task, e models.Exception) error {
+	horizon := services.Today().AddDate(0, 0, maxExceptionDays).Format(models.Layout)
+	dates := []string{e.Date}
+	if e.MovedTo != "" {
+		dates = append(dates, e.MovedTo)
+	}
+	for _, date := range dates {
+		if _, err := time.Parse(models.Layout, date); err != nil {
+			return fmt.Errorf("invalid date: %s", date)
+		}
+		if date > horizon {
+			return fmt.Errorf("%s is more than %d days ahead", date, maxExceptionDays)
+		}
+	}
+	if e.MovedTo == e.Date {
+		return errors.New("an occurrence can't be moved to its own date")
+	}
+	if e.MovedTo != "" && e.MovedTo < services.Today().Format(models.Layout) {
+		return errors.New("an occurrence can't be moved into the past")
+	}
+
+	ok, err := services.IsOccurrence(task, e.Date)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("no upcoming occurrence on %s", e.Date)
+	}
+	return nil
+}
+
+// DeleteException removes the exception for an occurrence. An occurrence
+// that has already been skipped or moved stays so.
+func DeleteException(store db.Store, bus *events.Bus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		task, ok := taskFromQuery(w, r, store)
+		if !ok {
+			return
+		}
+
+		err := commit(store, bus, func(b db.Batch) (events.Event, error) {
+			if err := b.DeleteException(task.Id, r.URL.Query().Get("date")); err != nil {
+				return events.Event{}, err
+			}
+			updated, err := b.GetTask(task.Id)
+			event := newEvent(r, events.TaskUpdated, updated)
+			event.Previous = &task
+			return event, err
+		})
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("exception not found"))
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, struct{}{})
+	}
+}
+
+// GetOccurrences previews the next count occurrences of a task with its
+// exceptions applied.
+func GetOccurrences(store db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		task, ok := taskFromQuery(w, r, store)
+		if !ok {
+			return
+		}
+
+		count := 10
+		if value := r.URL.Query().Get("count"); value != "" {
+			var err error
+			count, err = strconv.Atoi(value)
+			if err != nil || count < 1 || count > maxOccurrences {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("invalid count: %s", value))
+				return
+			}
+		}
+
+		occurrences, err := services.Occurrences(task, count)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string][]models.Occurrence{"occurrences": occurrences})
+	}
+}
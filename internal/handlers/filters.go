@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/auth"
+	"github.com/paran0iaa/TODO/internal/models"
+	"github.com/paran0iaa/TODO/internal/query"
+	"github.com/paran0iaa/TODO/internal/services"
+)
+
+func GetFilters(store db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filters, err := store.Filters(auth.User(r.Context()))
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string][]models.SavedFilter{"filters": filters})
+	}
+}
+
+func SaveFilter(store db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var f models.SavedFilter
+		if err := json.NewDecoder(r.Body).Decode(&f); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid filter json"))
+			return
+		}
+		if f.Name == "" {
+			writeError(w, http.StatusBadRequest, errors.New("filter name is required"))
+			return
+		}
+		if _, err := query.Parse(f.Query, services.Today()); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		id, err := store.SaveFilter(auth.User(r.Context()), f)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"id": id})
+	}
+}
+
+func DeleteFilter(store db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := store.DeleteFilter(auth.User(r.Context()), r.URL.Query().Get("id"))
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("filter not found"))
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, struct{}{})
+	}
+}
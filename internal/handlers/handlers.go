@@ -1,9 +1,22 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/auth"
+	"github.com/paran0iaa/TODO/internal/events"
+	"github.com/paran0iaa/TODO/internal/models"
+	"github.com/paran0iaa/TODO/internal/query"
 	"github.com/paran0iaa/TODO/internal/services"
 )
 
@@ -28,9 +41,563 @@ func NextDateHandler(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(result))
 }
 
-func CreateTask(w http.ResponseWriter, r *http.Request) {
-	if r.Method != http.MethodPost {
-		w.WriteHeader(http.StatusMethodNotAllowed)
-		return
+func CreateTask(store db.Store, bus *events.Bus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var task models.Task
+		if err := json.NewDecoder(r.Body).Decode(&task); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid task json"))
+			return
+		}
+
+		if err := services.CheckTask(&task, services.Now()); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err := commit(store, bus, func(b db.Batch) (events.Event, error) {
+			id, err := b.AddTask(task)
+			task.Id = id
+			return newEvent(r, events.TaskCreated, task), err
+		})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, map[string]string{"id": task.Id})
+	}
+}
+
+func GetTask(store db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		task, ok := taskFromQuery(w, r, store)
+		if !ok {
+			return
+		}
+		w.Header().Set("ETag", etag(task))
+		writeJSON(w, http.StatusOK, task)
+	}
+}
+
+func UpdateTask(store db.Store, bus *events.Bus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body taskBody
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid task json"))
+			return
+		}
+		if body.Id == "" {
+			writeError(w, http.StatusBadRequest, errors.New("task id is required"))
+			return
+		}
+
+		previous, err := store.GetTask(body.Id)
+		if err != nil {
+			writeTaskError(w, err)
+			return
+		}
+		task := body.keep(previous)
+		if err = services.CheckTask(&task, services.Now()); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		task.Version = ifMatch(r)
+
+		err = commit(store, bus, func(b db.Batch) (events.Event, error) {
+			if err := b.UpdateTask(task); err != nil {
+				return events.Event{}, err
+			}
+			var err error
+			task, err = b.GetTask(task.Id)
+			event := newEvent(r, events.TaskUpdated, task)
+			event.Previous = &previous
+			return event, err
+		})
+		if err != nil {
+			writeTaskError(w, err)
+			return
+		}
+
+		w.Header().Set("ETag", etag(task))
+		writeJSON(w, http.StatusOK, struct{}{})
+	}
+}
+
+// taskBody is a task sent to PUT /api/task. Tags, repeat_from, time and
+// estimate are kept as stored when the body leaves them out, since the web UI
+// and older clients only send the fields of the original task.
+type taskBody struct {
+	models.Task
+	sent map[string]bool
+}
+
+func (b *taskBody) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &b.Task); err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	b.sent = make(map[string]bool, len(fields))
+	for name := range fields {
+		b.sent[strings.ToLower(name)] = true
+	}
+	return nil
+}
+
+// keep returns the task with the fields the body left out taken from
+// previous.
+func (b taskBody) keep(previous models.Task) models.Task {
+	task := b.Task
+	if !b.sent["tags"] {
+		task.Tags = previous.Tags
+	}
+	if !b.sent["repeat_from"] {
+		task.RepeatFrom = previous.RepeatFrom
+	}
+	if !b.sent["time"] {
+		task.Time = previous.Time
+	}
+	if !b.sent["estimate"] {
+		task.Estimate = previous.Estimate
+	}
+	return task
+}
+
+// PatchTask applies a JSON Merge Patch to the task. Fields missing from the
+// patch are kept, and the date is only normalized like in PUT when the patch
+// sets the date or the repeat rule.
+func PatchTask(store db.Store, bus *events.Bus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		task, ok := taskFromQuery(w, r, store)
+		if !ok {
+			return
+		}
+
+		var patch map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&patch); err != nil || patch == nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid patch json: expected an object"))
+			return
+		}
+		delete(patch, "id")
+		for field := range patch {
+			if !patchable[field] {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("%s cannot be patched", field))
+				return
+			}
+		}
+
+		patched, err := mergeTask(task, patch)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if err = services.CheckTask(&patched, services.Now()); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		_, setsDate := patch["date"]
+		_, setsRepeat := patch["repeat"]
+		if !setsDate && !setsRepeat {
+			patched.Date = task.Date
+		}
+		patched.Version = ifMatch(r)
+
+		err = commit(store, bus, func(b db.Batch) (events.Event, error) {
+			if err := b.UpdateTask(patched); err != nil {
+				return events.Event{}, err
+			}
+			var err error
+			patched, err = b.GetTask(patched.Id)
+			event := newEvent(r, events.TaskUpdated, patched)
+			event.Previous = &task
+			return event, err
+		})
+		if err != nil {
+			writeTaskError(w, err)
+			return
+		}
+
+		w.Header().Set("ETag", etag(patched))
+		writeJSON(w, http.StatusOK, patched)
+	}
+}
+
+// patchable are the task fields a PATCH may set. The anchor and exceptions of
+// a recurring task are changed through snoozing and /api/task/exceptions.
+var patchable = map[string]bool{
+	"date": true, "title": true, "comment": true, "repeat": true, "tags": true,
+	"repeat_from": true, "time": true, "estimate": true,
+}
+
+func mergeTask(task models.Task, patch map[string]any) (models.Task, error) {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return models.Task{}, err
+	}
+	var doc map[string]any
+	if err = json.Unmarshal(data, &doc); err != nil {
+		return models.Task{}, err
+	}
+
+	if data, err = json.Marshal(services.MergePatch(doc, patch)); err != nil {
+		return models.Task{}, err
+	}
+	patched := models.Task{Id: task.Id}
+	if err = json.Unmarshal(data, &patched); err != nil {
+		return models.Task{}, errors.New("invalid patch json")
+	}
+	// blocked is derived from dependencies, not something a patch can set.
+	patched.Blocked = task.Blocked
+	return patched, nil
+}
+
+func DeleteTask(store db.Store, bus *events.Bus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		task, ok := taskFromQuery(w, r, store)
+		if !ok {
+			return
+		}
+
+		err := commit(store, bus, func(b db.Batch) (events.Event, error) {
+			event := newEvent(r, events.TaskDeleted, task)
+			return event, b.DeleteTask(task.Id, ifMatch(r), event.Time)
+		})
+		if err != nil {
+			writeTaskError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, struct{}{})
+	}
+}
+
+const (
+	tasksLimit    = 50
+	maxTasksLimit = 500
+	upcomingDays  = 7
+)
+
+type tasksPage struct {
+	Tasks      []models.Task `json:"tasks"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+}
+
+func GetTasks(store db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter := db.TaskFilter{
+			Ready:  r.URL.Query().Get("ready") == "true",
+			Search: r.URL.Query().Get("search"),
+			Sort:   r.URL.Query().Get("sort"),
+			Cursor: r.URL.Query().Get("cursor"),
+			Limit:  tasksLimit,
+		}
+		if filter.Sort != "" && !db.ValidSort(filter.Sort) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid sort: %s", filter.Sort))
+			return
+		}
+		if value := r.URL.Query().Get("limit"); value != "" {
+			limit, err := strconv.Atoi(value)
+			if err != nil || limit < 1 || limit > maxTasksLimit {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit: %s", value))
+				return
+			}
+			filter.Limit = limit
+		}
+		if err := applyView(&filter, r); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if err := applyQuery(&filter, r, store); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		tasks, next, err := store.TaskPage(filter)
+		if errors.Is(err, db.ErrInvalidCursor) {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, tasksPage{Tasks: tasks, NextCursor: next})
+	}
+}
+
+// applyView narrows filter to the view query parameter: today, upcoming
+// (the next days, 7 by default, after today) or overdue.
+func applyView(filter *db.TaskFilter, r *http.Request) error {
+	today := services.Today()
+
+	switch view := r.URL.Query().Get("view"); view {
+	case "":
+	case "today":
+		filter.DateFrom = today.Format(models.Layout)
+		filter.DateTo = filter.DateFrom
+	case "upcoming":
+		days := upcomingDays
+		if value := r.URL.Query().Get("days"); value != "" {
+			var err error
+			days, err = strconv.Atoi(value)
+			if err != nil || days < 1 {
+				return fmt.Errorf("invalid days: %s", value)
+			}
+		}
+		filter.DateFrom = today.AddDate(0, 0, 1).Format(models.Layout)
+		filter.DateTo = today.AddDate(0, 0, days).Format(models.Layout)
+	case "overdue":
+		filter.DateTo = today.AddDate(0, 0, -1).Format(models.Layout)
+		filter.OneOff = true
+	default:
+		return fmt.Errorf("invalid view: %s", view)
+	}
+	return nil
+}
+
+// applyQuery sets the filter query from q and from the user's saved filter
+// named by filter; both must match when both are given.
+func applyQuery(filter *db.TaskFilter, r *http.Request, store db.Store) error {
+	var queries []string
+	if name := r.URL.Query().Get("filter"); name != "" {
+		saved, err := store.GetFilter(auth.User(r.Context()), name)
+		if errors.Is(err, db.ErrNotFound) {
+			return fmt.Errorf("filter not found: %s", name)
+		}
+		if err != nil {
+			return err
+		}
+		queries = append(queries, saved.Query)
 	}
+	if q := r.URL.Query().Get("q"); q != "" {
+		queries = append(queries, q)
+	}
+
+	for _, q := range queries {
+		node, err := query.Parse(q, services.Today())
+		if err != nil {
+			return fmt.Errorf("invalid query: %w", err)
+		}
+		if filter.Query != nil {
+			node = query.And{Left: filter.Query, Right: node}
+		}
+		filter.Query = node
+	}
+	return nil
+}
+
+func TaskDone(store db.Store, bus *events.Bus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		task, ok := taskFromQuery(w, r, store)
+		if !ok {
+			return
+		}
+
+		// The body is optional and only carries a note for the history.
+		var body struct {
+			Note string `json:"note"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			writeError(w, http.StatusBadRequest, errors.New("invalid done json"))
+			return
+		}
+
+		completion, next, err := complete(task, body.Note)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		err = commit(store, bus, func(b db.Batch) (events.Event, error) {
+			event := newEvent(r, events.TaskCompleted, task)
+			event.Next = next.Date
+			return event, b.CompleteTask(completion, next)
+		})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, struct{}{})
+	}
+}
+
+// complete returns the completion record for task and its next occurrence,
+// which is empty for a one-off task.
+func complete(task models.Task, note string) (models.Completion, models.Occurrence, error) {
+	now := services.Now()
+	var next models.Occurrence
+	if task.Repeat != "" {
+		// A snoozed or moved occurrence continues the series from its anchor.
+		from := services.CurrentOccurrence(task).Scheduled
+		if task.RepeatFrom == models.RepeatFromCompletion {
+			from = now.Format(models.Layout)
+		}
+		var err error
+		next, err = services.NextOccurrence(now.Format(models.Layout), from, task.Repeat, task.Exceptions)
+		if err != nil {
+			return models.Completion{}, models.Occurrence{}, err
+		}
+	}
+
+	return models.Completion{
+		TaskId: task.Id,
+		Title:  task.Title,
+		Date:   task.Date,
+		DoneAt: now.Format(time.RFC3339),
+		Note:   note,
+	}, next, nil
+}
+
+func TaskHistory(store db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			writeError(w, http.StatusBadRequest, errors.New("task id is required"))
+			return
+		}
+
+		history, err := store.History(id)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string][]models.Completion{"history": history})
+	}
+}
+
+// taskFromQuery loads the task named by the id query parameter and writes an
+// error response when it can't.
+func taskFromQuery(w http.ResponseWriter, r *http.Request, store db.Store) (models.Task, bool) {
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		writeError(w, http.StatusBadRequest, errors.New("task id is required"))
+		return models.Task{}, false
+	}
+
+	task, err := store.GetTask(id)
+	if errors.Is(err, db.ErrNotFound) {
+		writeError(w, http.StatusNotFound, errors.New("task not found"))
+		return models.Task{}, false
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return models.Task{}, false
+	}
+	return task, true
+}
+
+var errTaskChanged = errors.New("task has been changed since it was read")
+
+func etag(task models.Task) string {
+	return `"` + strconv.Itoa(task.Version) + `"`
+}
+
+// ifMatch returns the task version required by the If-Match header, or 0
+// when any version will do. A header that is not one of our ETags, including
+// a weak one, yields -1, which no task has.
+func ifMatch(r *http.Request) int {
+	return matchVersion(r.Header.Get("If-Match"))
+}
+
+// matchVersion parses an If-Match value, see ifMatch.
+func matchVersion(header string) int {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return 0
+	}
+
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return -1
+	}
+	version, err := strconv.Atoi(header[1 : len(header)-1])
+	if err != nil || version < 1 {
+		return -1
+	}
+	return version
+}
+
+// commit makes a task change through fn and records the event fn returns in
+// the same transaction, so that a change is never committed without its
+// audit entry and webhook deliveries. The event is published once the change
+// is.
+func commit(store db.Store, bus *events.Bus, fn func(b db.Batch) (events.Event, error)) error {
+	var event events.Event
+	err := store.Batch(func(b db.Batch) error {
+		var err error
+		if event, err = fn(b); err != nil {
+			return err
+		}
+		return record(b, event)
+	})
+	if err != nil {
+		return err
+	}
+	bus.Publish(event)
+	return nil
+}
+
+// record appends event to the audit log and queues its webhook deliveries.
+func record(b db.Batch, event events.Event) error {
+	if err := b.AddAuditEntry(services.AuditEntry(event)); err != nil {
+		return err
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	return b.EnqueueDeliveries(event.Type, string(payload), services.Now().UTC().Format(time.RFC3339))
+}
+
+func newEvent(r *http.Request, eventType string, task models.Task) events.Event {
+	return events.Event{
+		Type: eventType,
+		Time: services.Now().Format(time.RFC3339),
+		User: auth.User(r.Context()),
+		Task: task,
+	}
+}
+
+// touch runs fn, a change to the checklist, dependencies or reminder of a
+// task, and reports it as an update of the task.
+func touch(r *http.Request, b db.Batch, id string, fn func() error) (events.Event, error) {
+	previous, err := b.GetTask(id)
+	if err != nil {
+		return events.Event{}, err
+	}
+	if err = fn(); err != nil {
+		return events.Event{}, err
+	}
+	task, err := b.GetTask(id)
+	if err != nil {
+		return events.Event{}, err
+	}
+	event := newEvent(r, events.TaskUpdated, task)
+	event.Previous = &previous
+	return event, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("failed to write response: %v", err)
+	}
+}
+
+// writeTaskError writes the response for a failed change of a task.
+func writeTaskError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		writeError(w, http.StatusNotFound, errors.New("task not found"))
+	case errors.Is(err, db.ErrConflict):
+		writeError(w, http.StatusPreconditionFailed, errTaskChanged)
+	default:
+		writeError(w, http.StatusInternalServerError, err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
 }
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/events"
+	"github.com/paran0iaa/TODO/internal/models"
+	"github.com/paran0iaa/TODO/internal/quickadd"
+	"github.com/paran0iaa/TODO/internal/services"
+)
+
+type quickResult struct {
+	Id   string      `json:"id"`
+	Task models.Task `json:"task"`
+	At   string      `json:"at,omitempty"`
+}
+
+// QuickAdd creates a task from a line of text such as "pay rent every month
+// on the 1st". The response echoes the parsed task so that clients can show
+// what was understood.
+func QuickAdd(store db.Store, bus *events.Bus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Text string `json:"text"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid quick add json"))
+			return
+		}
+
+		res, err := quickadd.Parser{Now: services.Now}.Parse(req.Text)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		task := res.Task
+		task.Time = res.At
+		if err := services.CheckTask(&task, services.Now()); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err = commit(store, bus, func(b db.Batch) (events.Event, error) {
+			id, err := b.AddTask(task)
+			task.Id = id
+			return newEvent(r, events.TaskCreated, task), err
+		})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, quickResult{Id: task.Id, Task: task, At: res.At})
+	}
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/events"
+	"github.com/paran0iaa/TODO/internal/models"
+	"github.com/paran0iaa/TODO/internal/notify"
+	"github.com/paran0iaa/TODO/internal/services"
+)
+
+func GetReminder(store db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reminder, err := store.GetReminder(r.URL.Query().Get("id"))
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("reminder not found"))
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, reminder)
+	}
+}
+
+func SetReminder(store db.Store, bus *events.Bus, notifiers map[string]notify.Notifier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var reminder models.Reminder
+		if err := json.NewDecoder(r.Body).Decode(&reminder); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid reminder json"))
+			return
+		}
+		reminder.TaskId = r.URL.Query().Get("id")
+
+		if err := services.CheckReminder(reminder); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		if _, ok := notifiers[reminder.Channel]; !ok {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("unknown reminder channel: %s", reminder.Channel))
+			return
+		}
+
+		err := commit(store, bus, func(b db.Batch) (events.Event, error) {
+			return touch(r, b, reminder.TaskId, func() error {
+				return b.SetReminder(reminder)
+			})
+		})
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("task not found"))
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, struct{}{})
+	}
+}
+
+func DeleteReminder(store db.Store, bus *events.Bus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		err := commit(store, bus, func(b db.Batch) (events.Event, error) {
+			return touch(r, b, id, func() error {
+				return b.DeleteReminder(id)
+			})
+		})
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("reminder not found"))
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, struct{}{})
+	}
+}
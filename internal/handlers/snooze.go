@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/events"
+	"github.com/paran0iaa/TODO/internal/models"
+	"github.com/paran0iaa/TODO/internal/services"
+)
+
+var snoozePeriod = regexp.MustCompile(`^(\d{1,3})([dw])$`)
+
+// SnoozeTask postpones a task by a period such as 1d, 3d or 1w, counted from
+// its date or from today if it is overdue, or until a given day. For a
+// recurring task only the current occurrence moves.
+func SnoozeTask(store db.Store, bus *events.Bus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		task, ok := taskFromQuery(w, r, store)
+		if !ok {
+			return
+		}
+
+		date, err := snoozeDate(task, r.URL.Query().Get("by"), r.URL.Query().Get("until"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		task.Version = ifMatch(r)
+
+		var snoozed models.Task
+		err = commit(store, bus, func(b db.Batch) (events.Event, error) {
+			if err := b.SnoozeTask(task, date); err != nil {
+				return events.Event{}, err
+			}
+			var err error
+			snoozed, err = b.GetTask(task.Id)
+			event := newEvent(r, events.TaskUpdated, snoozed)
+			event.Previous = &task
+			return event, err
+		})
+		if err != nil {
+			writeTaskError(w, err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, snoozed)
+	}
+}
+
+func snoozeDate(task models.Task, by, until string) (string, error) {
+	today := services.Today()
+	switch {
+	case by != "" && until != "":
+		return "", errors.New("either by or until is expected, not both")
+
+	case by != "":
+		m := snoozePeriod.FindStringSubmatch(by)
+		if m == nil {
+			return "", fmt.Errorf("invalid snooze period: %s", by)
+		}
+		n, _ := strconv.Atoi(m[1])
+		if n == 0 {
+			return "", errors.New("snooze period must be positive")
+		}
+		if m[2] == "w" {
+			n *= 7
+		}
+		from, err := time.ParseInLocation(models.Layout, task.Date, today.Location())
+		if err != nil {
+			return "", fmt.Errorf("invalid task date: %w", err)
+		}
+		if from.Before(today) {
+			from = today
+		}
+		return from.AddDate(0, 0, n).Format(models.Layout), nil
+
+	case until != "":
+		date, err := time.ParseInLocation(models.Layout, until, today.Location())
+		if err != nil {
+			return "", fmt.Errorf("invalid date: %s", until)
+		}
+		if until <= task.Date || date.Before(today) {
+			return "", errors.New("a task can only be snoozed forward")
+		}
+		return until, nil
+
+	default:
+		return "", errors.New("snooze period is required")
+	}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/models"
+	"github.com/paran0iaa/TODO/internal/services"
+)
+
+const (
+	// statsDays is the length of the default GET /api/stats range.
+	statsDays    = 28
+	maxStatsDays = 366
+)
+
+func GetStats(store db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := services.Now()
+		today := services.Today()
+
+		period := r.URL.Query().Get("period")
+		if period == "" {
+			period = services.PeriodDay
+		}
+
+		to, err := dateParam(r, "to", today)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		from, err := dateParam(r, "from", to.AddDate(0, 0, 1-statsDays))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if to.After(from.AddDate(0, 0, maxStatsDays-1)) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("the range is longer than %d days", maxStatsDays))
+			return
+		}
+
+		tasks, err := store.AllTasks()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		// Current streaks run up to today even when the range ends earlier.
+		until := to
+		if until.Before(today) {
+			until = today
+		}
+		completions, err := store.Completions(until)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		stats, err := services.Stats(tasks, completions, now, from, to, period)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, stats)
+	}
+}
+
+// dateParam parses an optional 20060102 query parameter in the local time zone.
+func dateParam(r *http.Request, name string, def time.Time) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+	date, err := time.ParseInLocation(models.Layout, value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %s", name, value)
+	}
+	return date, nil
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/events"
+	"github.com/paran0iaa/TODO/internal/models"
+	"github.com/paran0iaa/TODO/internal/services"
+)
+
+func GetTemplates(store db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		templates, err := store.Templates()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string][]models.Template{"templates": templates})
+	}
+}
+
+func SaveTemplate(store db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var t models.Template
+		if err := json.NewDecoder(r.Body).Decode(&t); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid template json"))
+			return
+		}
+		if err := checkTemplate(&t); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		id, err := store.SaveTemplate(t)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"id": id})
+	}
+}
+
+// checkTemplate validates t the way a task is validated and normalizes its
+// tags.
+func checkTemplate(t *models.Template) error {
+	if t.Name == "" {
+		return errors.New("template name is required")
+	}
+	for _, item := range t.Checklist {
+		if item == "" {
+			return errors.New("checklist item title is required")
+		}
+	}
+	task := models.Task{Title: t.Title, Repeat: t.Repeat, Tags: t.Tags}
+	if err := services.CheckTask(&task, services.Now()); err != nil {
+		return err
+	}
+	t.Tags = task.Tags
+	return nil
+}
+
+func DeleteTemplate(store db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := store.DeleteTemplate(r.URL.Query().Get("id"))
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("template not found"))
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, struct{}{})
+	}
+}
+
+// InstantiateTemplate creates a task from a template. The body may give the
+// task date and the template variables; {{date}} is the task date unless set
+// explicitly.
+func InstantiateTemplate(store db.Store, bus *events.Bus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		t, err := store.GetTemplate(r.URL.Query().Get("id"))
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("template not found"))
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		var body struct {
+			Date string            `json:"date"`
+			Vars map[string]string `json:"vars"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+			writeError(w, http.StatusBadRequest, errors.New("invalid template json"))
+			return
+		}
+		if body.Date == "" {
+			body.Date = services.Today().Format(models.Layout)
+		}
+		// {{date}} is the date the task ends up on, so the requested one is
+		// normalised and moved like any task's before it is filled in.
+		dated := models.Task{Title: t.Title, Date: body.Date, Repeat: t.Repeat}
+		if err := services.CheckTask(&dated, services.Now()); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		vars := map[string]string{"date": dated.Date}
+		for name, value := range body.Vars {
+			vars[name] = value
+		}
+
+		task, checklist, err := services.FillTemplate(t, vars)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		task.Date = dated.Date
+		if err := services.CheckTask(&task, services.Now()); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		err = commit(store, bus, func(b db.Batch) (events.Event, error) {
+			id, err := b.AddTaskWithChecklist(task, checklist)
+			task.Id = id
+			return newEvent(r, events.TaskCreated, task), err
+		})
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		writeJSON(w, http.StatusCreated, map[string]string{"id": task.Id})
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/auth"
+	"github.com/paran0iaa/TODO/internal/events"
+	"github.com/paran0iaa/TODO/internal/models"
+	"github.com/paran0iaa/TODO/internal/services"
+)
+
+func GetTrash(store db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		tasks, err := store.Trash()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string][]models.TrashedTask{"tasks": tasks})
+	}
+}
+
+// RestoreTask brings a task back from the trash. Subscribers see it as
+// created again.
+func RestoreTask(store db.Store, bus *events.Bus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		if id == "" {
+			writeError(w, http.StatusBadRequest, errors.New("task id is required"))
+			return
+		}
+
+		var task models.Task
+		err := commit(store, bus, func(b db.Batch) (events.Event, error) {
+			if err := b.RestoreTask(id); err != nil {
+				return events.Event{}, err
+			}
+			var err error
+			task, err = b.GetTask(id)
+			return newEvent(r, events.TaskCreated, task), err
+		})
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("task not found in trash"))
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, task)
+	}
+}
+
+// PurgeTrash deletes the trashed task given by id for good, or empties the
+// trash without one.
+func PurgeTrash(store db.Store, bus *events.Bus) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("id")
+		purge := func(b db.Batch) ([]models.TrashedTask, error) {
+			return b.PurgeTrash("")
+		}
+		if id != "" {
+			purge = func(b db.Batch) ([]models.TrashedTask, error) {
+				task, err := b.PurgeTask(id)
+				return []models.TrashedTask{task}, err
+			}
+		}
+
+		purged, err := Purge(store, bus, auth.User(r.Context()), purge)
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("task not found in trash"))
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]int{"purged": purged})
+	}
+}
+
+// Purge deletes trashed tasks for good through fn and, like commit, records
+// a purge of each in the same transaction. user is who purged them, empty
+// when the server purges expired tasks itself.
+func Purge(store db.Store, bus *events.Bus, user string, fn func(b db.Batch) ([]models.TrashedTask, error)) (int, error) {
+	var purged []events.Event
+	err := store.Batch(func(b db.Batch) error {
+		tasks, err := fn(b)
+		if err != nil {
+			return err
+		}
+		for _, task := range tasks {
+			event := events.Event{
+				Type: events.TaskPurged,
+				Time: services.Now().Format(time.RFC3339),
+				User: user,
+				Task: task.Task,
+			}
+			if err = record(b, event); err != nil {
+				return err
+			}
+			purged = append(purged, event)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, event := range purged {
+		bus.Publish(event)
+	}
+	return len(purged), nil
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+
+	db "github.com/paran0iaa/TODO/DataBase"
+	"github.com/paran0iaa/TODO/internal/events"
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+const deliveriesLimit = 50
+
+func GetWebhooks(store db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		webhooks, err := store.Webhooks()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		for i := range webhooks {
+			webhooks[i].Secret = ""
+		}
+		writeJSON(w, http.StatusOK, map[string][]models.Webhook{"webhooks": webhooks})
+	}
+}
+
+// AddWebhook registers a webhook. The secret is generated when it isn't
+// given and is only ever returned here.
+func AddWebhook(store db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var wh models.Webhook
+		if err := json.NewDecoder(r.Body).Decode(&wh); err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("invalid webhook json"))
+			return
+		}
+
+		u, err := url.Parse(wh.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid webhook url: %s", wh.URL))
+			return
+		}
+		for _, e := range wh.Events {
+			if !slices.Contains(events.Types, e) {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("unknown event: %s", e))
+				return
+			}
+		}
+		if wh.Secret == "" {
+			secret := make([]byte, 32)
+			if _, err = rand.Read(secret); err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			wh.Secret = hex.EncodeToString(secret)
+		}
+
+		id, err := store.AddWebhook(wh)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, map[string]string{"id": id, "secret": wh.Secret})
+	}
+}
+
+func DeleteWebhook(store db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := store.DeleteWebhook(r.URL.Query().Get("id"))
+		if errors.Is(err, db.ErrNotFound) {
+			writeError(w, http.StatusNotFound, errors.New("webhook not found"))
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, struct{}{})
+	}
+}
+
+func GetDeliveries(store db.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := r.URL.Query().Get("status")
+		if status != "" && status != db.DeliveryPending && status != db.DeliveryDelivered && status != db.DeliveryFailed {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid status: %s", status))
+			return
+		}
+
+		deliveries, err := store.Deliveries(r.URL.Query().Get("webhook_id"), status, deliveriesLimit)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string][]models.Delivery{"deliveries": deliveries})
+	}
+}
@@ -1,13 +1,180 @@
 package models
 
 type Task struct {
-	Id      string `json:"id,omitempty"`
+	Id      string   `json:"id,omitempty"`
+	Date    string   `json:"date"`
+	Title   string   `json:"title"`
+	Comment string   `json:"comment,omitempty"`
+	Repeat  string   `json:"repeat"`
+	Tags    []string `json:"tags,omitempty"`
+	Blocked bool     `json:"blocked,omitempty"`
+	// Snippet is the highlighted match of a text search.
+	Snippet string `json:"snippet,omitempty"`
+	// Version counts changes to the task and is sent as its ETag.
+	Version int `json:"-"`
+	// Anchor is the scheduled date of a recurring task whose current
+	// occurrence has been snoozed or moved to Date. The series continues
+	// from it.
+	Anchor string `json:"anchor,omitempty"`
+	// RepeatFrom is RepeatFromCompletion when the next occurrence is counted
+	// from the day the task is done rather than from its date.
+	RepeatFrom string `json:"repeat_from,omitempty"`
+	// Exceptions skip or move single occurrences of a recurring task.
+	Exceptions []Exception `json:"exceptions,omitempty"`
+	// Time is the time of day the task is planned for as 15:04, and
+	// Estimate how many minutes it takes.
+	Time     string `json:"time,omitempty"`
+	Estimate int    `json:"estimate,omitempty"`
+}
+
+const RepeatFromCompletion = "completion"
+
+// Exception changes the occurrence a repeat rule schedules on Date: it is
+// moved to MovedTo, or skipped when MovedTo is empty.
+type Exception struct {
 	Date    string `json:"date"`
-	Title   string `json:"title"`
-	Comment string `json:"comment,omitempty"`
-	Repeat  string `json:"repeat"`
+	MovedTo string `json:"moved_to,omitempty"`
+}
+
+// Occurrence is one date of a task. Scheduled is the date the repeat rule
+// gives it, which differs from Date when the occurrence has been moved.
+type Occurrence struct {
+	Date      string `json:"date"`
+	Scheduled string `json:"scheduled"`
+}
+
+// CalendarEntry is an occurrence of a task in a calendar range.
+type CalendarEntry struct {
+	Occurrence
+	Task Task `json:"task"`
+}
+
+// ForecastDay sums up the occurrences due on a day. Overloaded is set when
+// they exceed the daily capacity.
+type ForecastDay struct {
+	Date       string    `json:"date"`
+	Tasks      int       `json:"tasks"`
+	Minutes    int       `json:"minutes"`
+	Overloaded bool      `json:"overloaded"`
+	Overlaps   []Overlap `json:"overlaps,omitempty"`
+}
+
+// Overlap is the time two tasks planned for the same day share, from From to
+// To. Tasks without an estimate overlap only by starting at the same time.
+type Overlap struct {
+	Tasks [2]string `json:"tasks"`
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+}
+
+type ChecklistItem struct {
+	Id       string `json:"id"`
+	TaskId   string `json:"task_id"`
+	Position int    `json:"position"`
+	Title    string `json:"title"`
+	Done     bool   `json:"done"`
+}
+
+// Completion records that an occurrence of a task was done. Date is the
+// occurrence that was completed and DoneAt the RFC 3339 time it happened.
+type Completion struct {
+	Id     string `json:"id,omitempty"`
+	TaskId string `json:"task_id"`
+	Title  string `json:"title"`
+	Date   string `json:"date"`
+	DoneAt string `json:"done_at"`
+	Note   string `json:"note,omitempty"`
+}
+
+// Reminder fires DaysBefore days ahead of the task date at At (15:04) and is
+// sent once per occurrence; LastSent holds the occurrence date it was sent for.
+type Reminder struct {
+	TaskId     string `json:"task_id"`
+	DaysBefore int    `json:"days_before"`
+	At         string `json:"at"`
+	Channel    string `json:"channel"`
+	Target     string `json:"target"`
+	LastSent   string `json:"last_sent,omitempty"`
+}
+
+// TaskExport is a task with what is attached to it, as written by the export
+// command. Dependencies are the ids of its prerequisites within the export.
+type TaskExport struct {
+	Task
+	Checklist    []ChecklistItem `json:"checklist,omitempty"`
+	Dependencies []string        `json:"dependencies,omitempty"`
+	Reminder     *Reminder       `json:"reminder,omitempty"`
+	History      []Completion    `json:"history,omitempty"`
+}
+
+// Webhook receives task lifecycle events. Events lists the event types to
+// send; an empty list means all of them. Secret signs the deliveries.
+type Webhook struct {
+	Id     string   `json:"id,omitempty"`
+	URL    string   `json:"url"`
+	Secret string   `json:"secret,omitempty"`
+	Events []string `json:"events"`
+}
+
+// Delivery is one queued or finished webhook call. Status is pending,
+// delivered or failed; times are RFC 3339.
+type Delivery struct {
+	Id           string `json:"id"`
+	WebhookId    string `json:"webhook_id"`
+	Event        string `json:"event"`
+	Payload      string `json:"payload"`
+	Status       string `json:"status"`
+	Attempts     int    `json:"attempts"`
+	NextAttempt  string `json:"next_attempt,omitempty"`
+	ResponseCode int    `json:"response_code,omitempty"`
+	LastError    string `json:"last_error,omitempty"`
+	CreatedAt    string `json:"created_at"`
+	DeliveredAt  string `json:"delivered_at,omitempty"`
+}
+
+// SavedFilter is a named /api/tasks?q= query belonging to a user.
+type SavedFilter struct {
+	Id    string `json:"id,omitempty"`
+	Name  string `json:"name"`
+	Query string `json:"q"`
+}
+
+// Template is a task saved for reuse. Its text may contain {{variables}}
+// that are filled in when a task is created from it.
+type Template struct {
+	Id        string   `json:"id,omitempty"`
+	Name      string   `json:"name"`
+	Title     string   `json:"title"`
+	Comment   string   `json:"comment,omitempty"`
+	Repeat    string   `json:"repeat"`
+	Tags      []string `json:"tags,omitempty"`
+	Checklist []string `json:"checklist,omitempty"`
 }
 
 const (
-	Layout string = "20060102"
+	Layout     string = "20060102"
+	TimeLayout string = "15:04"
 )
+
+type TrashedTask struct {
+	Task
+	DeletedAt string `json:"deleted_at"`
+}
+
+// AuditEntry records one change to a task. Old and New are the task before
+// and after the change, nil for a created or deleted task respectively.
+type AuditEntry struct {
+	Id      string                 `json:"id"`
+	Time    string                 `json:"time"`
+	User    string                 `json:"user"`
+	Action  string                 `json:"action"`
+	TaskId  string                 `json:"task_id"`
+	Old     *Task                  `json:"old"`
+	New     *Task                  `json:"new"`
+	Changes map[string]FieldChange `json:"changes"`
+}
+
+type FieldChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
@@ -0,0 +1,38 @@
+package models
+
+type Stats struct {
+	Period string        `json:"period"`
+	From   string        `json:"from"`
+	To     string        `json:"to"`
+	Totals StatsTotals   `json:"totals"`
+	Done   []PeriodCount `json:"done"`
+	Tasks  []TaskStats   `json:"tasks"`
+}
+
+type StatsTotals struct {
+	Completed  int     `json:"completed"`
+	OnTime     int     `json:"on_time"`
+	Late       int     `json:"late"`
+	OnTimeRate float64 `json:"on_time_rate"`
+	Open       int     `json:"open"`
+	Overdue    int     `json:"overdue"`
+}
+
+// PeriodCount is the number of completions in one day (20060102) or ISO week
+// (2006-W01) bucket.
+type PeriodCount struct {
+	Period string `json:"period"`
+	Count  int    `json:"count"`
+}
+
+type TaskStats struct {
+	TaskId        string  `json:"task_id"`
+	Title         string  `json:"title"`
+	Completed     int     `json:"completed"`
+	OnTime        int     `json:"on_time"`
+	Late          int     `json:"late"`
+	OnTimeRate    float64 `json:"on_time_rate"`
+	CurrentStreak int     `json:"current_streak"`
+	BestStreak    int     `json:"best_streak"`
+	LastDone      string  `json:"last_done"`
+}
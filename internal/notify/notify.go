@@ -0,0 +1,20 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+// Message is what a reminder tells about a task.
+type Message struct {
+	Subject string
+	Body    string
+	Task    models.Task
+}
+
+// Notifier delivers a message to a target whose meaning depends on the
+// channel, e.g. an email address or a URL.
+type Notifier interface {
+	Notify(ctx context.Context, target string, msg Message) error
+}
@@ -0,0 +1,44 @@
+package notify
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeliverSignsPayload(t *testing.T) {
+	payload := []byte(`{"type":"task.created"}`)
+
+	var got *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	code, err := Deliver(context.Background(), srv.Client(), srv.URL, "s3cret", "7", "task.created", payload)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, payload, body)
+	assert.Equal(t, "task.created", got.Header.Get("X-Todo-Event"))
+	assert.Equal(t, "7", got.Header.Get("X-Todo-Delivery"))
+	assert.Equal(t, Sign("s3cret", payload), got.Header.Get("X-Todo-Signature"))
+	assert.NotEqual(t, Sign("other", payload), got.Header.Get("X-Todo-Signature"))
+}
+
+func TestDeliverReportsFailedStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	code, err := Deliver(context.Background(), srv.Client(), srv.URL, "s3cret", "1", "task.deleted", []byte(`{}`))
+	assert.Error(t, err)
+	assert.Equal(t, http.StatusBadGateway, code)
+}
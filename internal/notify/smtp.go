@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+)
+
+// SMTP sends messages as plain text email. Auth is skipped when Username is
+// empty, which is what a local relay or a test stand-in expects.
+type SMTP struct {
+	Addr     string
+	From     string
+	Username string
+	Password string
+}
+
+// headerLine keeps a header value on one line.
+var headerLine = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+func (s SMTP) Notify(ctx context.Context, target string, msg Message) error {
+	if strings.ContainsAny(target, "\r\n") {
+		return fmt.Errorf("invalid email address: %q", target)
+	}
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, err := net.SplitHostPort(s.Addr)
+		if err != nil {
+			return fmt.Errorf("invalid smtp address: %w", err)
+		}
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.From)
+	fmt.Fprintf(&b, "To: %s\r\n", target)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", headerLine.Replace(msg.Subject)))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	b.WriteString("\r\n")
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- smtp.SendMail(s.Addr, auth, s.From, []string{target}, []byte(b.String()))
+	}()
+
+	select {
+	case err := <-errc:
+		if err != nil {
+			return fmt.Errorf("failed to send email: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
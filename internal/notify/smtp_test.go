@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"context"
+	"mime"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"github.com/paran0iaa/TODO/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// smtpStandIn accepts a single message without TLS or auth and sends what it
+// received to the returned channel.
+func smtpStandIn(t *testing.T) (string, <-chan string) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		tp := textproto.NewConn(conn)
+		tp.PrintfLine("220 localhost ESMTP")
+		var data strings.Builder
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			switch cmd := strings.ToUpper(strings.Fields(line + " ")[0]); cmd {
+			case "EHLO", "HELO":
+				tp.PrintfLine("250 localhost")
+			case "DATA":
+				tp.PrintfLine("354 go ahead")
+				lines, err := tp.ReadDotLines()
+				if err != nil {
+					return
+				}
+				data.WriteString(strings.Join(lines, "\n"))
+				tp.PrintfLine("250 queued")
+			case "QUIT":
+				tp.PrintfLine("221 bye")
+				received <- data.String()
+				return
+			default:
+				tp.PrintfLine("250 ok")
+			}
+		}
+	}()
+	return ln.Addr().String(), received
+}
+
+func TestSMTPNotify(t *testing.T) {
+	addr, received := smtpStandIn(t)
+
+	n := SMTP{Addr: addr, From: "todo@example.com"}
+	err := n.Notify(context.Background(), "me@example.com", Message{
+		Subject: "Reminder: Проверить бэкапы",
+		Body:    "Проверить бэкапы is due on 26.01.2024.\n",
+		Task:    models.Task{Id: "1", Title: "Проверить бэкапы"},
+	})
+	require.NoError(t, err)
+
+	msg := <-received
+	assert.Contains(t, msg, "To: me@example.com")
+	assert.Contains(t, msg, "Subject: "+mime.QEncoding.Encode("utf-8", "Reminder: Проверить бэкапы"))
+	assert.Contains(t, msg, "is due on 26.01.2024.")
+}
+
+func TestSMTPNotifyRejectsHeaderInjection(t *testing.T) {
+	n := SMTP{Addr: "127.0.0.1:1", From: "todo@example.com"}
+	err := n.Notify(context.Background(), "me@example.com\r\nBcc: x@example.com", Message{})
+	assert.Error(t, err)
+}
+
+func TestSMTPNotifyEncodesSubject(t *testing.T) {
+	addr, received := smtpStandIn(t)
+
+	n := SMTP{Addr: addr, From: "todo@example.com"}
+	err := n.Notify(context.Background(), "me@example.com", Message{
+		Subject: "Reminder: Отчёт\r\nBcc: x@example.com\nX-Spam: 1",
+		Body:    "Отчёт is due today.\n",
+	})
+	require.NoError(t, err)
+
+	msg := <-received
+	headers, _, _ := strings.Cut(msg, "\n\n")
+	var subject string
+	for _, line := range strings.Split(headers, "\n") {
+		name, value, _ := strings.Cut(line, ": ")
+		assert.NotContains(t, []string{"Bcc", "X-Spam"}, name)
+		if name == "Subject" {
+			subject = value
+		}
+	}
+	decoded, err := new(mime.WordDecoder).DecodeHeader(subject)
+	require.NoError(t, err)
+	assert.Equal(t, "Reminder: Отчёт Bcc: x@example.com X-Spam: 1", decoded)
+}
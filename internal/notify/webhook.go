@@ -0,0 +1,56 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+// Webhook posts messages as JSON to the target URL.
+type Webhook struct {
+	Client *http.Client
+}
+
+type webhookPayload struct {
+	Event   string      `json:"event"`
+	Subject string      `json:"subject"`
+	Body    string      `json:"body"`
+	Task    models.Task `json:"task"`
+}
+
+func (wh Webhook) Notify(ctx context.Context, target string, msg Message) error {
+	data, err := json.Marshal(webhookPayload{
+		Event:   "task.reminder",
+		Subject: msg.Subject,
+		Body:    msg.Body,
+		Task:    msg.Task,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("invalid webhook url: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := wh.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
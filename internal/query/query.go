@@ -0,0 +1,334 @@
+// Package query parses the task filter language of /api/tasks?q=.
+//
+// A query is a list of terms that must all match. Terms can be joined with
+// OR, negated with a leading - or NOT and grouped with parentheses:
+//
+//	tag:release due<20250101 repeat:w* "deploy"
+//	(tag:home OR tag:family) -is:recurring
+//
+// A bare word or "quoted phrase" is looked up in titles and comments. Field
+// terms are field:value or, for dates, field<value, field<=value,
+// field>value and field>=value. String values may use * as a wildcard.
+package query
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Fields that a term may name. due and date are the same field.
+const (
+	FieldText    = ""
+	FieldTitle   = "title"
+	FieldComment = "comment"
+	FieldTag     = "tag"
+	FieldRepeat  = "repeat"
+	FieldDue     = "due"
+	FieldIs      = "is"
+)
+
+type Node interface {
+	node()
+}
+
+type And struct {
+	Left, Right Node
+}
+
+type Or struct {
+	Left, Right Node
+}
+
+type Not struct {
+	X Node
+}
+
+// Term compares a field with a value. Op is one of : < <= > >=; Value of a
+// due term is a 20060102 date.
+type Term struct {
+	Field string
+	Op    string
+	Value string
+}
+
+func (And) node()  {}
+func (Or) node()   {}
+func (Not) node()  {}
+func (Term) node() {}
+
+// Parse parses q. Relative dates (today, today+7, today-1) in due terms are
+// resolved against today.
+func Parse(q string, today time.Time) (Node, error) {
+	tokens, err := lex(q)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("empty query")
+	}
+
+	p := parser{tokens: tokens, today: today}
+	node, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if !p.done() {
+		return nil, fmt.Errorf("unexpected %q", p.peek().text)
+	}
+	return node, nil
+}
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenPhrase
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+}
+
+func lex(q string) ([]token, error) {
+	var tokens []token
+	runes := []rune(q)
+	for i := 0; i < len(runes); {
+		switch r := runes[i]; {
+		case r == ' ' || r == '\t' || r == '\n':
+			i++
+		case r == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")"})
+			i++
+		case r == '"':
+			end := indexRune(runes, i+1, '"')
+			if end < 0 {
+				return nil, errors.New("unterminated quote")
+			}
+			tokens = append(tokens, token{kind: tokenPhrase, text: string(runes[i+1 : end])})
+			i = end + 1
+		default:
+			// A word runs to the next space or parenthesis; a quoted part
+			// inside it, as in title:"weekly sync", may contain both.
+			var b strings.Builder
+			for i < len(runes) && !strings.ContainsRune(" \t\n()", runes[i]) {
+				if runes[i] == '"' {
+					end := indexRune(runes, i+1, '"')
+					if end < 0 {
+						return nil, errors.New("unterminated quote")
+					}
+					b.WriteString(string(runes[i+1 : end]))
+					i = end + 1
+					continue
+				}
+				b.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenWord, text: b.String()})
+		}
+	}
+	return tokens, nil
+}
+
+func indexRune(runes []rune, from int, r rune) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	today  time.Time
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) isKeyword(word string) bool {
+	return !p.done() && p.peek().kind == tokenWord && p.peek().text == word
+}
+
+func (p *parser) or() (Node, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.isKeyword("OR") {
+		p.pos++
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = Or{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (Node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for !p.done() && p.peek().kind != tokenRParen && !p.isKeyword("OR") {
+		if p.isKeyword("AND") {
+			p.pos++
+		}
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = And{Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) unary() (Node, error) {
+	if p.done() {
+		return nil, errors.New("unexpected end of query")
+	}
+
+	tok := p.peek()
+	switch {
+	case tok.kind == tokenWord && tok.text == "NOT":
+		p.pos++
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{X: x}, nil
+	case tok.kind == tokenWord && tok.text == "-":
+		// -( ... ) negates a group.
+		p.pos++
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{X: x}, nil
+	case tok.kind == tokenWord && strings.HasPrefix(tok.text, "-"):
+		p.pos++
+		x, err := p.term(tok.text[1:])
+		if err != nil {
+			return nil, err
+		}
+		return Not{X: x}, nil
+	case tok.kind == tokenLParen:
+		p.pos++
+		x, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if p.done() || p.peek().kind != tokenRParen {
+			return nil, errors.New("missing )")
+		}
+		p.pos++
+		return x, nil
+	case tok.kind == tokenRParen:
+		return nil, errors.New("unexpected )")
+	case tok.kind == tokenPhrase:
+		p.pos++
+		return Term{Field: FieldText, Op: ":", Value: tok.text}, nil
+	default:
+		p.pos++
+		return p.term(tok.text)
+	}
+}
+
+func (p *parser) term(word string) (Node, error) {
+	i := strings.IndexAny(word, ":<>")
+	if i <= 0 {
+		return Term{Field: FieldText, Op: ":", Value: word}, nil
+	}
+
+	field, rest := strings.ToLower(word[:i]), word[i:]
+	op := rest[:1]
+	if strings.HasPrefix(rest, "<=") || strings.HasPrefix(rest, ">=") {
+		op = rest[:2]
+	}
+	value := rest[len(op):]
+	if field == "date" {
+		field = FieldDue
+	}
+
+	switch field {
+	case FieldTitle, FieldComment, FieldTag, FieldRepeat, FieldIs:
+		if op != ":" {
+			return nil, fmt.Errorf("%s does not support %s", field, op)
+		}
+	case FieldDue:
+	default:
+		// Times and URLs are words too, not fields.
+		return Term{Field: FieldText, Op: ":", Value: word}, nil
+	}
+
+	switch field {
+	case FieldTag, FieldIs:
+		value = strings.ToLower(strings.TrimPrefix(value, "#"))
+	case FieldDue:
+		date, err := parseDate(value, p.today)
+		if err != nil {
+			return nil, err
+		}
+		value = date
+	}
+	if value == "" && field != FieldRepeat {
+		return nil, fmt.Errorf("empty value for %s", field)
+	}
+	if field == FieldIs {
+		return p.state(value)
+	}
+	return Term{Field: field, Op: op, Value: value}, nil
+}
+
+// state rewrites the is: shortcuts that can be expressed with other fields,
+// leaving is:blocked and is:ready as terms.
+func (p *parser) state(value string) (Node, error) {
+	oneOff := Term{Field: FieldRepeat, Op: ":", Value: ""}
+	switch value {
+	case "blocked", "ready":
+		return Term{Field: FieldIs, Op: ":", Value: value}, nil
+	case "oneoff":
+		return oneOff, nil
+	case "recurring":
+		return Not{X: oneOff}, nil
+	case "overdue":
+		return And{Left: oneOff, Right: Term{Field: FieldDue, Op: "<", Value: p.today.Format("20060102")}}, nil
+	default:
+		return nil, fmt.Errorf("unknown state: is:%s", value)
+	}
+}
+
+func parseDate(value string, today time.Time) (string, error) {
+	const layout = "20060102"
+
+	if rest, ok := strings.CutPrefix(value, "today"); ok {
+		days := 0
+		if rest != "" {
+			if _, err := fmt.Sscanf(rest, "%d", &days); err != nil || (rest[0] != '+' && rest[0] != '-') {
+				return "", fmt.Errorf("invalid date: %s", value)
+			}
+		}
+		return today.AddDate(0, 0, days).Format(layout), nil
+	}
+
+	date, err := time.Parse(layout, value)
+	if err != nil {
+		return "", fmt.Errorf("invalid date: %s", value)
+	}
+	return date.Format(layout), nil
+}
@@ -0,0 +1,66 @@
+package query
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var today = time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)
+
+func TestParse(t *testing.T) {
+	node, err := Parse(`tag:#Release due<today+7 "deploy now"`, today)
+	require.NoError(t, err)
+	assert.Equal(t, And{
+		Left: And{
+			Left:  Term{Field: FieldTag, Op: ":", Value: "release"},
+			Right: Term{Field: FieldDue, Op: "<", Value: "20240317"},
+		},
+		Right: Term{Field: FieldText, Op: ":", Value: "deploy now"},
+	}, node)
+
+	node, err = Parse(`(tag:home OR tag:family) -is:recurring`, today)
+	require.NoError(t, err)
+	assert.Equal(t, And{
+		Left: Or{
+			Left:  Term{Field: FieldTag, Op: ":", Value: "home"},
+			Right: Term{Field: FieldTag, Op: ":", Value: "family"},
+		},
+		Right: Not{X: Not{X: Term{Field: FieldRepeat, Op: ":", Value: ""}}},
+	}, node)
+
+	node, err = Parse(`date>=20240101 title:"weekly sync"`, today)
+	require.NoError(t, err)
+	assert.Equal(t, And{
+		Left:  Term{Field: FieldDue, Op: ">=", Value: "20240101"},
+		Right: Term{Field: FieldTitle, Op: ":", Value: "weekly sync"},
+	}, node)
+
+	node, err = Parse(`standup 12:30 https://example.com`, today)
+	require.NoError(t, err)
+	assert.Equal(t, And{
+		Left: And{
+			Left:  Term{Field: FieldText, Op: ":", Value: "standup"},
+			Right: Term{Field: FieldText, Op: ":", Value: "12:30"},
+		},
+		Right: Term{Field: FieldText, Op: ":", Value: "https://example.com"},
+	}, node)
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, q := range []string{
+		``,
+		`"deploy`,
+		`(tag:home`,
+		`tag:home)`,
+		`tag<x`,
+		`due:tomorrow`,
+		`is:done`,
+		`tag:`,
+	} {
+		_, err := Parse(q, today)
+		assert.Error(t, err, q)
+	}
+}
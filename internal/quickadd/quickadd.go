@@ -0,0 +1,475 @@
+// Package quickadd turns a one-line description of a task, in English or
+// Russian, into a task:
+//
+//	Позвонить маме завтра в 18:00 каждую неделю #family
+//	pay rent every month on the 1st
+//
+// It recognises dates (today, завтра, in 3 days, через неделю, on friday,
+// 25.12, 5 мая), repeats (every day, каждые 3 дня, every monday and
+// thursday, по пятницам, every month on the 1st, ежегодно), a time of day
+// and #tags. The remaining words are the title.
+package quickadd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+// maxRepeatDays is the longest interval a d repeat rule allows.
+const maxRepeatDays = 400
+
+// Parser parses quick-add text against the day given by its clock.
+type Parser struct {
+	Now func() time.Time
+}
+
+type Result struct {
+	Task models.Task
+	// At is the time of day the text mentions as 15:04, if any.
+	At string
+}
+
+type repeatKind int
+
+const (
+	noRepeat repeatKind = iota
+	everyDays
+	onWeekdays
+	monthly
+	yearly
+)
+
+type state struct {
+	today time.Time
+	raw   []string
+	words []string
+
+	date     time.Time
+	repeat   repeatKind
+	days     int
+	weekdays []int
+	monthDay int
+	at       string
+	tags     []string
+}
+
+func (p Parser) Parse(text string) (Result, error) {
+	now := p.Now()
+	s := &state{
+		today: time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()),
+		raw:   strings.Fields(text),
+	}
+	for _, w := range s.raw {
+		s.words = append(s.words, normalize(w))
+	}
+
+	var title []string
+	for i := 0; i < len(s.words); {
+		n, err := s.match(i)
+		if err != nil {
+			return Result{}, err
+		}
+		if n == 0 {
+			title = append(title, s.raw[i])
+			n = 1
+		}
+		i += n
+	}
+	if len(title) == 0 {
+		return Result{}, errors.New("task title is required")
+	}
+
+	task := models.Task{Title: strings.Join(title, " "), Tags: s.tags}
+	if err := s.resolve(&task); err != nil {
+		return Result{}, err
+	}
+	return Result{Task: task, At: s.at}, nil
+}
+
+// normalize lower-cases a word and drops the punctuation around it.
+func normalize(word string) string {
+	word = strings.ToLower(word)
+	word = strings.TrimLeft(word, `"'(«`)
+	return strings.TrimRight(word, `"'),.!?;:»`)
+}
+
+func (s *state) word(i int) string {
+	if i < 0 || i >= len(s.words) {
+		return ""
+	}
+	return s.words[i]
+}
+
+// match recognises a phrase starting at word i and returns its length, or 0
+// when the word belongs to the title.
+func (s *state) match(i int) (int, error) {
+	if tag := strings.TrimPrefix(s.word(i), "#"); tag != s.word(i) && tag != "" {
+		s.tags = append(s.tags, tag)
+		return 1, nil
+	}
+	for _, recognise := range []func(int) (int, error){s.repetition, s.dayOfMonth, s.timeOfDay, s.day} {
+		if n, err := recognise(i); n > 0 || err != nil {
+			return n, err
+		}
+	}
+	return 0, nil
+}
+
+func (s *state) setRepeat(kind repeatKind) error {
+	if s.repeat != noRepeat {
+		return errors.New("more than one repeat rule")
+	}
+	s.repeat = kind
+	return nil
+}
+
+// repetition recognises every day, every 3 weeks, каждый понедельник,
+// по пятницам, daily, ежемесячно and the like.
+func (s *state) repetition(i int) (int, error) {
+	switch s.word(i) {
+	case "daily", "ежедневно":
+		s.days = 1
+		return 1, s.setRepeat(everyDays)
+	case "weekly", "еженедельно":
+		s.days = 7
+		return 1, s.setRepeat(everyDays)
+	case "monthly", "ежемесячно":
+		return 1, s.setRepeat(monthly)
+	case "yearly", "annually", "ежегодно":
+		return 1, s.setRepeat(yearly)
+	case "по":
+		if days, n := s.weekdayList(i + 1); n > 0 {
+			s.weekdays = days
+			return 1 + n, s.setRepeat(onWeekdays)
+		}
+		return 0, nil
+	}
+	if !everyWords[s.word(i)] {
+		return 0, nil
+	}
+
+	j := i + 1
+	count := 1
+	if s.word(j) == "other" {
+		count, j = 2, j+1
+	} else if n, ok := number(s.word(j)); ok {
+		count, j = n, j+1
+	}
+
+	switch units[s.word(j)] {
+	case dayUnit:
+		s.days = count
+		return j + 1 - i, s.setRepeat(everyDays)
+	case weekUnit:
+		s.days = 7 * count
+		return j + 1 - i, s.setRepeat(everyDays)
+	case monthUnit:
+		if count != 1 {
+			return 0, errors.New("only monthly repeats are supported")
+		}
+		return j + 1 - i, s.setRepeat(monthly)
+	case yearUnit:
+		if count != 1 {
+			return 0, errors.New("only yearly repeats are supported")
+		}
+		return j + 1 - i, s.setRepeat(yearly)
+	}
+
+	if s.word(j) == "weekday" || (s.word(j) == "будний" && s.word(j+1) == "день") {
+		s.weekdays = []int{1, 2, 3, 4, 5}
+		n := 1
+		if s.word(j) == "будний" {
+			n = 2
+		}
+		return j + n - i, s.setRepeat(onWeekdays)
+	}
+	if days, n := s.weekdayList(j); n > 0 && count == 1 {
+		s.weekdays = days
+		return j + n - i, s.setRepeat(onWeekdays)
+	}
+	return 0, nil
+}
+
+// weekdayList recognises monday, monday and thursday, mon tue fri.
+func (s *state) weekdayList(i int) ([]int, int) {
+	var days []int
+	j := i
+	for {
+		day, ok := weekdays[s.word(j)]
+		if !ok {
+			break
+		}
+		days = append(days, day)
+		j++
+		if andWords[s.word(j)] {
+			if _, ok := weekdays[s.word(j+1)]; ok {
+				j++
+			}
+		}
+	}
+	return days, j - i
+}
+
+// dayOfMonth recognises on the 1st, on the last day, 15-го числа. English
+// days need the on, so that watch the 2nd season stays a title.
+func (s *state) dayOfMonth(i int) (int, error) {
+	j := i
+	if s.word(j) == "on" || s.word(j) == "в" {
+		j++
+	}
+	on := s.word(i) == "on"
+	if on && s.word(j) == "the" {
+		j++
+	}
+	if (on && s.word(j) == "last" && s.word(j+1) == "day") || (s.word(j) == "последний" && s.word(j+1) == "день") {
+		s.monthDay = -1
+		return j + 2 - i, nil
+	}
+	if s.word(j) == "последнего" && s.word(j+1) == "числа" {
+		s.monthDay = -1
+		return j + 2 - i, nil
+	}
+
+	if day, ok := ordinal(s.word(j)); ok && (j > i || strings.HasSuffix(s.word(j), "го") || s.word(j+1) == "числа") {
+		s.monthDay = day
+		if s.word(j+1) == "числа" || s.word(j+1) == "число" {
+			j++
+		}
+		return j + 1 - i, nil
+	}
+	if day, err := strconv.Atoi(s.word(j)); err == nil && j == i && (s.word(j+1) == "числа" || s.word(j+1) == "число") {
+		if day < 1 || day > 31 {
+			return 0, fmt.Errorf("invalid day of month: %d", day)
+		}
+		s.monthDay = day
+		return 2, nil
+	}
+	return 0, nil
+}
+
+var (
+	clockTime = regexp.MustCompile(`^(\d{1,2}):(\d{2})$`)
+	ampmTime  = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)$`)
+)
+
+// timeOfDay recognises 18:00, в 18:00, at 6pm and at 6:30 pm.
+func (s *state) timeOfDay(i int) (int, error) {
+	j := i
+	if atWords[s.word(j)] {
+		j++
+	}
+
+	word := s.word(j)
+	if suffix := s.word(j + 1); suffix == "am" || suffix == "pm" {
+		word, j = word+suffix, j+1
+	}
+
+	var hour, minute int
+	if m := ampmTime.FindStringSubmatch(word); m != nil {
+		hour, _ = strconv.Atoi(m[1])
+		minute, _ = strconv.Atoi(m[2])
+		if hour < 1 || hour > 12 {
+			return 0, fmt.Errorf("invalid time: %s", word)
+		}
+		hour %= 12
+		if m[3] == "pm" {
+			hour += 12
+		}
+	} else if m := clockTime.FindStringSubmatch(word); m != nil {
+		hour, _ = strconv.Atoi(m[1])
+		minute, _ = strconv.Atoi(m[2])
+	} else {
+		return 0, nil
+	}
+	if hour > 23 || minute > 59 {
+		return 0, fmt.Errorf("invalid time: %s", word)
+	}
+	if s.at != "" {
+		return 0, errors.New("more than one time")
+	}
+	s.at = fmt.Sprintf("%02d:%02d", hour, minute)
+	return j + 1 - i, nil
+}
+
+// day recognises today, завтра, in 3 days, через неделю, on friday, 25.12,
+// 25.12.2025, 20251225 and 5 мая.
+func (s *state) day(i int) (int, error) {
+	var (
+		date time.Time
+		n    int
+	)
+	switch w := s.word(i); {
+	case w == "today" || w == "сегодня":
+		date, n = s.today, 1
+	case w == "tomorrow" || w == "завтра":
+		date, n = s.today.AddDate(0, 0, 1), 1
+	case w == "послезавтра":
+		date, n = s.today.AddDate(0, 0, 2), 1
+	case w == "day" && s.word(i+1) == "after" && s.word(i+2) == "tomorrow":
+		date, n = s.today.AddDate(0, 0, 2), 3
+	case w == "the" && s.word(i+1) == "day" && s.word(i+2) == "after" && s.word(i+3) == "tomorrow":
+		date, n = s.today.AddDate(0, 0, 2), 4
+	case inWords[w]:
+		date, n = s.after(i + 1)
+		if n == 0 {
+			return 0, nil
+		}
+		n++
+	default:
+		j := i
+		if onWords[w] {
+			j++
+		}
+		if date, n = s.calendarDay(j); n == 0 {
+			return 0, nil
+		}
+		n += j - i
+	}
+
+	if !s.date.IsZero() {
+		return 0, errors.New("more than one date")
+	}
+	s.date = date
+	return n, nil
+}
+
+// after recognises the 3 days or неделю of in 3 days or через неделю.
+func (s *state) after(i int) (time.Time, int) {
+	count, j := 1, i
+	if n, ok := number(s.word(j)); ok {
+		count, j = n, j+1
+	}
+	switch units[s.word(j)] {
+	case dayUnit:
+		return s.today.AddDate(0, 0, count), j + 1 - i
+	case weekUnit:
+		return s.today.AddDate(0, 0, 7*count), j + 1 - i
+	case monthUnit:
+		return s.today.AddDate(0, count, 0), j + 1 - i
+	case yearUnit:
+		return s.today.AddDate(count, 0, 0), j + 1 - i
+	}
+	return time.Time{}, 0
+}
+
+// calendarDay recognises a weekday, which means its next occurrence after
+// today, and a date with or without the year; without one it is the next
+// such date from today on.
+func (s *state) calendarDay(i int) (time.Time, int) {
+	w := s.word(i)
+	if day, ok := weekdays[w]; ok {
+		return nextWeekday(s.today.AddDate(0, 0, 1), []int{day}), 1
+	}
+
+	for _, layout := range []string{"02.01.2006", "2.1.2006", models.Layout, "2006-01-02"} {
+		if date, err := time.ParseInLocation(layout, w, s.today.Location()); err == nil {
+			return date, 1
+		}
+	}
+	for _, layout := range []string{"02.01", "2.1"} {
+		if date, err := time.ParseInLocation(layout, w, s.today.Location()); err == nil {
+			return s.upcoming(date.Month(), date.Day()), 1
+		}
+	}
+
+	// 5 мая, 5 may and may 5.
+	if day, err := strconv.Atoi(w); err == nil && day >= 1 && day <= 31 {
+		if month, ok := months[s.word(i+1)]; ok {
+			return s.upcoming(time.Month(month), day), 2
+		}
+	}
+	if month, ok := months[w]; ok {
+		day, err := strconv.Atoi(s.word(i + 1))
+		if err != nil {
+			day, ok = ordinal(s.word(i + 1))
+		}
+		if (err == nil || ok) && day >= 1 && day <= 31 {
+			return s.upcoming(time.Month(month), day), 2
+		}
+	}
+	return time.Time{}, 0
+}
+
+func (s *state) upcoming(month time.Month, day int) time.Time {
+	date := time.Date(s.today.Year(), month, day, 0, 0, 0, 0, s.today.Location())
+	if date.Before(s.today) {
+		date = date.AddDate(1, 0, 0)
+	}
+	return date
+}
+
+// nextWeekday returns the first day from from on that falls on one of days,
+// where Monday is 1 and Sunday is 7.
+func nextWeekday(from time.Time, days []int) time.Time {
+	for i := 0; i < 7; i++ {
+		day := from.AddDate(0, 0, i)
+		weekday := int(day.Weekday())
+		if weekday == 0 {
+			weekday = 7
+		}
+		for _, d := range days {
+			if d == weekday {
+				return day
+			}
+		}
+	}
+	return from
+}
+
+// nextMonthDay returns the first day from from on that is the given day of
+// its month, where -1 is the last day.
+func nextMonthDay(from time.Time, monthDay int) time.Time {
+	for i := 0; i < 366; i++ {
+		day := from.AddDate(0, 0, i)
+		last := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+		if day.Day() == monthDay || (monthDay == -1 && day.Day() == last) {
+			return day
+		}
+	}
+	return from
+}
+
+// resolve fills in the date and the repeat rule of task.
+func (s *state) resolve(task *models.Task) error {
+	date := s.date
+	if date.IsZero() {
+		switch {
+		case s.repeat == onWeekdays:
+			date = nextWeekday(s.today, s.weekdays)
+		case s.monthDay != 0:
+			date = nextMonthDay(s.today, s.monthDay)
+		default:
+			date = s.today
+		}
+	}
+	task.Date = date.Format(models.Layout)
+
+	switch s.repeat {
+	case everyDays:
+		if s.days > maxRepeatDays {
+			return fmt.Errorf("repeat interval is longer than %d days", maxRepeatDays)
+		}
+		task.Repeat = fmt.Sprintf("d %d", s.days)
+	case onWeekdays:
+		days := make([]string, len(s.weekdays))
+		for i, d := range s.weekdays {
+			days[i] = strconv.Itoa(d)
+		}
+		task.Repeat = "w " + strings.Join(days, ",")
+	case monthly:
+		day := s.monthDay
+		if day == 0 {
+			day = date.Day()
+		}
+		task.Repeat = fmt.Sprintf("m %d", day)
+	case yearly:
+		task.Repeat = "y"
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package quickadd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+// Sunday.
+var parser = Parser{Now: func() time.Time {
+	return time.Date(2024, 3, 10, 9, 30, 0, 0, time.UTC)
+}}
+
+func TestParse(t *testing.T) {
+	tbl := []struct {
+		text string
+		task models.Task
+		at   string
+	}{
+		{"Позвонить маме завтра в 18:00 каждую неделю #family",
+			models.Task{Title: "Позвонить маме", Date: "20240311", Repeat: "d 7", Tags: []string{"family"}}, "18:00"},
+		{"pay rent every month on the 1st",
+			models.Task{Title: "pay rent", Date: "20240401", Repeat: "m 1"}, ""},
+		{"buy milk", models.Task{Title: "buy milk", Date: "20240310"}, ""},
+		{"buy milk today", models.Task{Title: "buy milk", Date: "20240310"}, ""},
+		{"dentist tomorrow at 6pm", models.Task{Title: "dentist", Date: "20240311"}, "18:00"},
+		{"dentist at 9:15 am the day after tomorrow", models.Task{Title: "dentist", Date: "20240312"}, "09:15"},
+		{"Сдать отчёт послезавтра", models.Task{Title: "Сдать отчёт", Date: "20240312"}, ""},
+		{"renew passport in 3 weeks", models.Task{Title: "renew passport", Date: "20240331"}, ""},
+		{"Продлить страховку через месяц", models.Task{Title: "Продлить страховку", Date: "20240410"}, ""},
+		{"review PR on friday", models.Task{Title: "review PR", Date: "20240315"}, ""},
+		{"Совещание в пятницу", models.Task{Title: "Совещание", Date: "20240315"}, ""},
+		{"call Bob next sunday", models.Task{Title: "call Bob", Date: "20240317"}, ""},
+		{"Оплатить налог 25.12", models.Task{Title: "Оплатить налог", Date: "20241225"}, ""},
+		{"Оплатить налог 01.03.2025", models.Task{Title: "Оплатить налог", Date: "20250301"}, ""},
+		{"Подарок маме 5 мая #family #gifts",
+			models.Task{Title: "Подарок маме", Date: "20240505", Tags: []string{"family", "gifts"}}, ""},
+		{"party on march 2nd", models.Task{Title: "party", Date: "20250302"}, ""},
+		{"water plants every 3 days", models.Task{Title: "water plants", Date: "20240310", Repeat: "d 3"}, ""},
+		{"water plants every other day", models.Task{Title: "water plants", Date: "20240310", Repeat: "d 2"}, ""},
+		{"Полить цветы каждые 2 недели", models.Task{Title: "Полить цветы", Date: "20240310", Repeat: "d 14"}, ""},
+		{"gym every monday and thursday",
+			models.Task{Title: "gym", Date: "20240311", Repeat: "w 1,4"}, ""},
+		{"Бассейн по средам и пятницам в 7:30",
+			models.Task{Title: "Бассейн", Date: "20240313", Repeat: "w 3,5"}, "07:30"},
+		{"standup every weekday", models.Task{Title: "standup", Date: "20240311", Repeat: "w 1,2,3,4,5"}, ""},
+		{"Зарплата каждый месяц 10-го числа", models.Task{Title: "Зарплата", Date: "20240310", Repeat: "m 10"}, ""},
+		{"Отчёт ежемесячно в последний день", models.Task{Title: "Отчёт", Date: "20240331", Repeat: "m -1"}, ""},
+		{"backup monthly", models.Task{Title: "backup", Date: "20240310", Repeat: "m 10"}, ""},
+		{"День рождения Пети 5 мая ежегодно",
+			models.Task{Title: "День рождения Пети", Date: "20240505", Repeat: "y"}, ""},
+		{"watch the 2nd season", models.Task{Title: "watch the 2nd season", Date: "20240310"}, ""},
+		{"read the last day chapter", models.Task{Title: "read the last day chapter", Date: "20240310"}, ""},
+		{"finish the 3rd chapter on the 15th", models.Task{Title: "finish the 3rd chapter", Date: "20240315"}, ""},
+		{"invoice every month on the last day", models.Task{Title: "invoice", Date: "20240331", Repeat: "m -1"}, ""},
+		{"Сдать 2-е задание", models.Task{Title: "Сдать 2-е задание", Date: "20240310"}, ""},
+	}
+
+	for _, v := range tbl {
+		res, err := parser.Parse(v.text)
+		require.NoError(t, err, v.text)
+		assert.Equal(t, v.task, res.Task, v.text)
+		assert.Equal(t, v.at, res.At, v.text)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, text := range []string{
+		"",
+		"tomorrow at 18:00",
+		"#work",
+		"call mom today tomorrow",
+		"call mom every day every week",
+		"call mom at 25:00",
+		"call mom every 2 months",
+		"call mom every 100 weeks",
+	} {
+		_, err := parser.Parse(text)
+		assert.Error(t, err, text)
+	}
+}
@@ -0,0 +1,96 @@
+package quickadd
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Word lists are lower case. Russian entries cover the inflected forms that
+// follow the prepositions and quantifiers the parser knows.
+
+var weekdays = map[string]int{
+	"monday": 1, "mon": 1, "mondays": 1,
+	"tuesday": 2, "tue": 2, "tues": 2, "tuesdays": 2,
+	"wednesday": 3, "wed": 3, "wednesdays": 3,
+	"thursday": 4, "thu": 4, "thur": 4, "thurs": 4, "thursdays": 4,
+	"friday": 5, "fri": 5, "fridays": 5,
+	"saturday": 6, "sat": 6, "saturdays": 6,
+	"sunday": 7, "sun": 7, "sundays": 7,
+
+	"понедельник": 1, "понедельника": 1, "понедельникам": 1, "пн": 1,
+	"вторник": 2, "вторника": 2, "вторникам": 2, "вт": 2,
+	"среда": 3, "среду": 3, "среды": 3, "средам": 3, "ср": 3,
+	"четверг": 4, "четверга": 4, "четвергам": 4, "чт": 4,
+	"пятница": 5, "пятницу": 5, "пятницы": 5, "пятницам": 5, "пт": 5,
+	"суббота": 6, "субботу": 6, "субботы": 6, "субботам": 6, "сб": 6,
+	"воскресенье": 7, "воскресенья": 7, "воскресеньям": 7, "вс": 7,
+}
+
+var months = map[string]int{
+	"january": 1, "jan": 1, "января": 1,
+	"february": 2, "feb": 2, "февраля": 2,
+	"march": 3, "mar": 3, "марта": 3,
+	"april": 4, "apr": 4, "апреля": 4,
+	"may": 5, "мая": 5,
+	"june": 6, "jun": 6, "июня": 6,
+	"july": 7, "jul": 7, "июля": 7,
+	"august": 8, "aug": 8, "августа": 8,
+	"september": 9, "sep": 9, "sept": 9, "сентября": 9,
+	"october": 10, "oct": 10, "октября": 10,
+	"november": 11, "nov": 11, "ноября": 11,
+	"december": 12, "dec": 12, "декабря": 12,
+}
+
+type unit int
+
+const (
+	noUnit unit = iota
+	dayUnit
+	weekUnit
+	monthUnit
+	yearUnit
+)
+
+var units = map[string]unit{
+	"day": dayUnit, "days": dayUnit, "день": dayUnit, "дня": dayUnit, "дней": dayUnit,
+	"week": weekUnit, "weeks": weekUnit, "неделя": weekUnit, "неделю": weekUnit, "недели": weekUnit, "недель": weekUnit,
+	"month": monthUnit, "months": monthUnit, "месяц": monthUnit, "месяца": monthUnit, "месяцев": monthUnit,
+	"year": yearUnit, "years": yearUnit, "год": yearUnit, "года": yearUnit, "лет": yearUnit,
+}
+
+var (
+	everyWords = set("every", "each", "каждый", "каждую", "каждое", "каждые", "каждой")
+	inWords    = set("in", "через")
+	onWords    = set("on", "next", "this", "в", "во", "на")
+	atWords    = set("at", "в", "во")
+	andWords   = set("and", "и", "&")
+)
+
+func set(words ...string) map[string]bool {
+	m := make(map[string]bool, len(words))
+	for _, w := range words {
+		m[w] = true
+	}
+	return m
+}
+
+// number parses a count: digits, or a and an for one.
+func number(word string) (int, bool) {
+	if word == "a" || word == "an" || word == "one" || word == "один" || word == "одну" {
+		return 1, true
+	}
+	n, err := strconv.Atoi(word)
+	return n, err == nil && n > 0
+}
+
+// ordinal parses an English day of the month such as 1st or 22nd and a
+// Russian one such as 1-го or 15-е.
+func ordinal(word string) (int, bool) {
+	for _, suffix := range []string{"st", "nd", "rd", "th", "-го", "-е", "-ое", "го"} {
+		if digits, ok := strings.CutSuffix(word, suffix); ok {
+			n, err := strconv.Atoi(digits)
+			return n, err == nil && n >= 1 && n <= 31
+		}
+	}
+	return 0, false
+}
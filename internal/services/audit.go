@@ -0,0 +1,93 @@
+package services
+
+import (
+	"slices"
+	"time"
+
+	"github.com/paran0iaa/TODO/internal/events"
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+// AuditEntry describes the change an event reports as an audit log entry.
+func AuditEntry(e events.Event) models.AuditEntry {
+	entry := models.AuditEntry{
+		Time:   e.Time,
+		User:   e.User,
+		Action: events.Actions[e.Type],
+		TaskId: e.Task.Id,
+	}
+	// Times are kept in UTC so that the log sorts and filters as text.
+	if t, err := time.Parse(time.RFC3339, e.Time); err == nil {
+		entry.Time = t.UTC().Format(time.RFC3339)
+	}
+
+	task := e.Task
+	switch e.Type {
+	case events.TaskCreated:
+		entry.New = &task
+	case events.TaskUpdated:
+		entry.Old, entry.New = e.Previous, &task
+	case events.TaskCompleted:
+		entry.Old = &task
+		if e.Next != "" {
+			next := task
+			next.Date = e.Next
+			entry.New = &next
+		}
+	case events.TaskDeleted, events.TaskPurged:
+		entry.Old = &task
+	}
+	entry.Changes = TaskChanges(entry.Old, entry.New)
+	return entry
+}
+
+// TaskChanges returns the fields that differ between two states of a task,
+// keyed by their JSON names. A nil state counts as having no fields set.
+func TaskChanges(old, new *models.Task) map[string]models.FieldChange {
+	var before, after models.Task
+	if old != nil {
+		before = *old
+	}
+	if new != nil {
+		after = *new
+	}
+
+	changes := make(map[string]models.FieldChange)
+	for _, f := range []struct {
+		name     string
+		old, new string
+	}{
+		{"date", before.Date, after.Date},
+		{"title", before.Title, after.Title},
+		{"comment", before.Comment, after.Comment},
+		{"repeat", before.Repeat, after.Repeat},
+		{"repeat_from", before.RepeatFrom, after.RepeatFrom},
+		{"anchor", before.Anchor, after.Anchor},
+		{"time", before.Time, after.Time},
+	} {
+		if f.old != f.new {
+			changes[f.name] = models.FieldChange{Old: f.old, New: f.new}
+		}
+	}
+	if before.Estimate != after.Estimate {
+		changes["estimate"] = models.FieldChange{Old: before.Estimate, New: after.Estimate}
+	}
+	if !slices.Equal(before.Tags, after.Tags) {
+		changes["tags"] = models.FieldChange{Old: before.Tags, New: after.Tags}
+	}
+	if !slices.Equal(before.Exceptions, after.Exceptions) {
+		changes["exceptions"] = models.FieldChange{Old: before.Exceptions, New: after.Exceptions}
+	}
+
+	// A created or deleted task shows as null rather than empty fields.
+	for name, change := range changes {
+		if old == nil {
+			change.Old = nil
+		}
+		if new == nil {
+			change.New = nil
+		}
+		changes[name] = change
+	}
+	return changes
+}
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+// Calendar expands tasks into their occurrences due between from and to
+// inclusive, ordered by date.
+func Calendar(tasks []models.Task, from, to string) ([]models.CalendarEntry, error) {
+	entries := []models.CalendarEntry{}
+	for _, task := range tasks {
+		occurrences, err := OccurrencesBetween(task, from, to)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand task %s: %w", task.Id, err)
+		}
+		for _, o := range occurrences {
+			entries = append(entries, models.CalendarEntry{Occurrence: o, Task: task})
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Date < entries[j].Date
+	})
+	return entries, nil
+}
@@ -0,0 +1,29 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+// Now is the server clock. Handlers read the time through it so that tests
+// can pin it, either directly or with TODO_NOW.
+var Now = time.Now
+
+// FixClock pins Now to noon of the given 20060102 day in the local time zone.
+func FixClock(date string) error {
+	day, err := time.ParseInLocation(models.Layout, date, time.Local)
+	if err != nil {
+		return fmt.Errorf("invalid date: %s", date)
+	}
+	fixed := day.Add(12 * time.Hour)
+	Now = func() time.Time { return fixed }
+	return nil
+}
+
+// Today returns the start of the current day on the server clock.
+func Today() time.Time {
+	now := Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+// Capacity is how much work fits in a day. A zero limit is no limit.
+type Capacity struct {
+	Minutes int
+	Tasks   int
+}
+
+func (c Capacity) exceeded(day models.ForecastDay) bool {
+	return (c.Minutes > 0 && day.Minutes > c.Minutes) || (c.Tasks > 0 && day.Tasks > c.Tasks)
+}
+
+// Forecast sums up the calendar entries for each day from from to to, empty
+// days included, and finds the tasks whose times overlap. A task counts
+// towards the day it starts on, but one that runs past midnight overlaps the
+// tasks of the next day too, so entries should include the day before from.
+func Forecast(entries []models.CalendarEntry, from, to string, capacity Capacity) ([]models.ForecastDay, error) {
+	start, err := time.Parse(models.Layout, from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date: %s", from)
+	}
+
+	byDate := make(map[string][]models.Task)
+	for _, e := range entries {
+		byDate[e.Date] = append(byDate[e.Date], e.Task)
+	}
+
+	days := []models.ForecastDay{}
+	for date := start; date.Format(models.Layout) <= to; date = date.AddDate(0, 0, 1) {
+		day := models.ForecastDay{Date: date.Format(models.Layout)}
+		tasks := byDate[day.Date]
+		for _, task := range tasks {
+			day.Tasks++
+			day.Minutes += task.Estimate
+		}
+		day.Overloaded = capacity.exceeded(day)
+		day.Overlaps = overlaps(tasks, byDate[date.AddDate(0, 0, -1).Format(models.Layout)])
+		days = append(days, day)
+	}
+	return days, nil
+}
+
+// overlaps finds the pairs of tasks whose planned time intersects on a day,
+// including the tasks of the day before that run past midnight. Since an
+// estimate is at most a day, they can't reach further.
+func overlaps(tasks, dayBefore []models.Task) []models.Overlap {
+	type span struct {
+		id         string
+		start, end int
+	}
+	var spans []span
+	for _, v := range []struct {
+		tasks  []models.Task
+		offset int
+	}{{dayBefore, -24 * 60}, {tasks, 0}} {
+		for _, task := range v.tasks {
+			at, err := time.Parse(models.TimeLayout, task.Time)
+			if err != nil {
+				continue
+			}
+			start := v.offset + at.Hour()*60 + at.Minute()
+			if end := start + task.Estimate; end > 0 {
+				spans = append(spans, span{task.Id, start, end})
+			}
+		}
+	}
+
+	var found []models.Overlap
+	for i, a := range spans {
+		for _, b := range spans[i+1:] {
+			if a.start != b.start && (a.start >= b.end || b.start >= a.end) {
+				continue
+			}
+			found = append(found, models.Overlap{
+				Tasks: [2]string{a.id, b.id},
+				From:  clock(max(a.start, b.start)),
+				To:    clock(min(a.end, b.end)),
+			})
+		}
+	}
+	return found
+}
+
+// clock formats minutes since midnight as 15:04, kept within the day: an
+// overlap that goes on past midnight is reported again for the next day.
+func clock(minutes int) string {
+	if minutes >= 24*60 {
+		return "24:00"
+	}
+	minutes = max(minutes, 0)
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"strings"
@@ -14,7 +15,7 @@ func stringToTime(dateString string) (time.Time, error) {
 }
 
 func isValidRepeatCode(code string) bool {
-	return code == "y" || code == "d"
+	return code == "y" || code == "d" || code == "w" || code == "m"
 }
 
 func NextDate(now, date, repeat string) (string, error) {
@@ -41,6 +42,16 @@ func NextDate(now, date, repeat string) (string, error) {
 			return "", fmt.Errorf("invalid day repeat format: %s", repeat)
 		}
 		return findNextDays(nowTime, startDate, codeAndNumber[1])
+	case "w":
+		if len(codeAndNumber) != 2 {
+			return "", fmt.Errorf("invalid week repeat format: %s", repeat)
+		}
+		return findNextWeekday(nowTime, startDate, codeAndNumber[1])
+	case "m":
+		if len(codeAndNumber) != 2 && len(codeAndNumber) != 3 {
+			return "", fmt.Errorf("invalid month repeat format: %s", repeat)
+		}
+		return findNextMonthDay(nowTime, startDate, codeAndNumber[1:])
 	default:
 		return "", fmt.Errorf("unknown repeat code: %s", codeAndNumber[0])
 	}
@@ -73,3 +84,80 @@ func findNextDays(nowTime, startDate time.Time, daysStr string) (string, error)
 		startDate = nextTime
 	}
 }
+
+// parseNumbers parses a comma-separated list of integers in [min, max].
+func parseNumbers(list string, min, max int) ([]int, error) {
+	var numbers []int
+	for _, part := range strings.Split(list, ",") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < min || n > max {
+			return nil, fmt.Errorf("invalid number: %s", part)
+		}
+		numbers = append(numbers, n)
+	}
+	return numbers, nil
+}
+
+func contains(numbers []int, n int) bool {
+	for _, v := range numbers {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
+
+// firstAfter returns the first day after both now and start that matches.
+// Day rules repeat within a few years at most, so the search is bounded.
+func firstAfter(nowTime, startDate time.Time, matches func(time.Time) bool) (string, error) {
+	day := startDate
+	if nowTime.After(day) {
+		day = nowTime
+	}
+	for i := 0; i < 366*8; i++ {
+		day = day.AddDate(0, 0, 1)
+		if matches(day) {
+			return day.Format(models.Layout), nil
+		}
+	}
+	return "", errors.New("repeat rule never matches")
+}
+
+// findNextWeekday handles "w 1,4": Monday is 1 and Sunday is 7.
+func findNextWeekday(nowTime, startDate time.Time, daysStr string) (string, error) {
+	days, err := parseNumbers(daysStr, 1, 7)
+	if err != nil {
+		return "", err
+	}
+	return firstAfter(nowTime, startDate, func(day time.Time) bool {
+		weekday := int(day.Weekday())
+		if weekday == 0 {
+			weekday = 7
+		}
+		return contains(days, weekday)
+	})
+}
+
+// findNextMonthDay handles "m 1,15" and "m -1 1,7": days of the month, where
+// -1 and -2 are the last and the next to last day, optionally limited to
+// the listed months.
+func findNextMonthDay(nowTime, startDate time.Time, rule []string) (string, error) {
+	days, err := parseNumbers(rule[0], -2, 31)
+	if err != nil || contains(days, 0) {
+		return "", fmt.Errorf("invalid month days: %s", rule[0])
+	}
+	var months []int
+	if len(rule) == 2 {
+		if months, err = parseNumbers(rule[1], 1, 12); err != nil {
+			return "", fmt.Errorf("invalid months: %s", rule[1])
+		}
+	}
+
+	return firstAfter(nowTime, startDate, func(day time.Time) bool {
+		if months != nil && !contains(months, int(day.Month())) {
+			return false
+		}
+		last := time.Date(day.Year(), day.Month()+1, 0, 0, 0, 0, 0, day.Location()).Day()
+		return contains(days, day.Day()) || contains(days, day.Day()-last-1)
+	})
+}
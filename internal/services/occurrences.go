@@ -0,0 +1,172 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+func exceptionOn(exceptions []models.Exception, date string) (models.Exception, bool) {
+	for _, e := range exceptions {
+		if e.Date == date {
+			return e, true
+		}
+	}
+	return models.Exception{}, false
+}
+
+// NextOccurrence returns the first occurrence of a series after now, where
+// start is a date of the series. Skipped occurrences are passed over and
+// moved ones are due on the day they were moved to, unless that is before
+// now, in which case they are passed over too. An empty Date means the rule
+// gives no more occurrences.
+func NextOccurrence(now, start, repeat string, exceptions []models.Exception) (models.Occurrence, error) {
+	scheduled := now
+	// Each skip uses up an exception, so this ends.
+	for range len(exceptions) + 1 {
+		next, err := NextDate(scheduled, start, repeat)
+		if err != nil || next == "" {
+			return models.Occurrence{}, err
+		}
+		e, ok := exceptionOn(exceptions, next)
+		if !ok {
+			return models.Occurrence{Date: next, Scheduled: next}, nil
+		}
+		if e.MovedTo >= now {
+			return models.Occurrence{Date: e.MovedTo, Scheduled: next}, nil
+		}
+		scheduled, start = next, next
+	}
+	return models.Occurrence{}, nil
+}
+
+// CurrentOccurrence returns the occurrence the task is due on now.
+func CurrentOccurrence(task models.Task) models.Occurrence {
+	if task.Anchor != "" {
+		return models.Occurrence{Date: task.Date, Scheduled: task.Anchor}
+	}
+	return models.Occurrence{Date: task.Date, Scheduled: task.Date}
+}
+
+// IsOccurrence tells whether the series of task schedules an occurrence on
+// date, counting from the current one.
+func IsOccurrence(task models.Task, date string) (bool, error) {
+	start := CurrentOccurrence(task).Scheduled
+	if date <= start {
+		return date == start, nil
+	}
+	before, err := dayBefore(date)
+	if err != nil {
+		return false, err
+	}
+	next, err := NextDate(before, start, task.Repeat)
+	return next == date, err
+}
+
+func dayBefore(date string) (string, error) {
+	day, err := time.Parse(models.Layout, date)
+	if err != nil {
+		return "", fmt.Errorf("invalid date: %s", date)
+	}
+	return day.AddDate(0, 0, -1).Format(models.Layout), nil
+}
+
+// Occurrences returns the first limit occurrences of task from its current
+// one on, with the exceptions applied, in order of date.
+func Occurrences(task models.Task, limit int) ([]models.Occurrence, error) {
+	current := CurrentOccurrence(task)
+	occurrences := []models.Occurrence{current}
+
+	if task.Repeat != "" {
+		for scheduled := current.Scheduled; len(occurrences) < limit; {
+			next, err := NextOccurrence(scheduled, scheduled, task.Repeat, task.Exceptions)
+			if err != nil {
+				return nil, err
+			}
+			if next.Date == "" {
+				break
+			}
+			occurrences = append(occurrences, next)
+			scheduled = next.Scheduled
+		}
+	}
+
+	sortOccurrences(occurrences)
+	return occurrences, nil
+}
+
+// OccurrencesBetween returns the occurrences of task due between from and to
+// inclusive, with the exceptions applied, in order of date. The series is
+// expanded from its first date on or after from rather than from the current
+// occurrence.
+func OccurrencesBetween(task models.Task, from, to string) ([]models.Occurrence, error) {
+	current := CurrentOccurrence(task)
+	occurrences := []models.Occurrence{current}
+
+	if task.Repeat != "" {
+		after := current.Scheduled
+		if from > after {
+			var err error
+			if after, err = dayBefore(from); err != nil {
+				return nil, err
+			}
+		}
+		for start := current.Scheduled; ; {
+			next, err := NextOccurrence(after, start, task.Repeat, task.Exceptions)
+			if err != nil {
+				return nil, err
+			}
+			if next.Date == "" || next.Scheduled > to {
+				break
+			}
+			occurrences = append(occurrences, next)
+			after, start = next.Scheduled, next.Scheduled
+		}
+
+		moved, err := movedIn(task, from, to)
+		if err != nil {
+			return nil, err
+		}
+		occurrences = append(occurrences, moved...)
+	}
+
+	inRange := occurrences[:0]
+	for _, o := range occurrences {
+		if o.Date >= from && o.Date <= to {
+			inRange = append(inRange, o)
+		}
+	}
+	sortOccurrences(inRange)
+	return inRange, nil
+}
+
+func sortOccurrences(occurrences []models.Occurrence) {
+	sort.SliceStable(occurrences, func(i, j int) bool {
+		return occurrences[i].Date < occurrences[j].Date
+	})
+}
+
+// movedIn returns the upcoming occurrences scheduled outside from to to that
+// have been moved into it.
+func movedIn(task models.Task, from, to string) ([]models.Occurrence, error) {
+	var occurrences []models.Occurrence
+	for _, e := range task.Exceptions {
+		if e.MovedTo == "" || e.MovedTo < from || e.MovedTo > to || (e.Date >= from && e.Date <= to) {
+			continue
+		}
+		if e.Date == CurrentOccurrence(task).Scheduled {
+			continue
+		}
+		// The rule may have changed since the exception was made.
+		ok, err := IsOccurrence(task, e.Date)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			occurrences = append(occurrences, models.Occurrence{Date: e.MovedTo, Scheduled: e.Date})
+		}
+	}
+	return occurrences, nil
+}
@@ -0,0 +1,28 @@
+package services
+
+// MergePatch applies a JSON Merge Patch (RFC 7396) to doc, both decoded into
+// generic values: patch members replace those of doc, null members remove
+// them and nested objects are merged recursively.
+func MergePatch(doc any, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	docObj, ok := doc.(map[string]any)
+	if !ok {
+		docObj = map[string]any{}
+	}
+	merged := make(map[string]any, len(docObj))
+	for k, v := range docObj {
+		merged[k] = v
+	}
+	for k, v := range patchObj {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+		merged[k] = MergePatch(merged[k], v)
+	}
+	return merged
+}
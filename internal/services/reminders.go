@@ -0,0 +1,39 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+const maxReminderDays = 365
+
+func CheckReminder(r models.Reminder) error {
+	if r.DaysBefore < 0 || r.DaysBefore > maxReminderDays {
+		return fmt.Errorf("invalid days_before: %d", r.DaysBefore)
+	}
+	if _, err := time.Parse(models.TimeLayout, r.At); err != nil {
+		return fmt.Errorf("invalid reminder time: %s", r.At)
+	}
+	if r.Target == "" {
+		return errors.New("reminder target is required")
+	}
+	return nil
+}
+
+// ReminderDue returns when the reminder for the task's current occurrence
+// should fire.
+func ReminderDue(r models.Reminder, task models.Task, loc *time.Location) (time.Time, error) {
+	date, err := time.ParseInLocation(models.Layout, task.Date, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date: %s", task.Date)
+	}
+	at, err := time.Parse(models.TimeLayout, r.At)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid reminder time: %s", r.At)
+	}
+	day := date.AddDate(0, 0, -r.DaysBefore)
+	return time.Date(day.Year(), day.Month(), day.Day(), at.Hour(), at.Minute(), 0, 0, loc), nil
+}
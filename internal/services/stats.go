@@ -0,0 +1,138 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+const (
+	PeriodDay  = "day"
+	PeriodWeek = "week"
+)
+
+// Stats aggregates completions for charts. A completion is on time when it
+// happened no later than the occurrence date, and a streak is a run of
+// consecutive on-time completions. Counts, rates, best streaks and the done
+// series, which includes empty buckets, cover completions done in [from, to].
+// Current streaks and the open and overdue totals are as of now.
+func Stats(tasks []models.Task, completions []models.Completion, now, from, to time.Time, period string) (models.Stats, error) {
+	if period != PeriodDay && period != PeriodWeek {
+		return models.Stats{}, fmt.Errorf("invalid period: %s", period)
+	}
+	if to.Before(from) {
+		return models.Stats{}, fmt.Errorf("invalid range: %s > %s", from.Format(models.Layout), to.Format(models.Layout))
+	}
+
+	stats := models.Stats{
+		Period: period,
+		From:   from.Format(models.Layout),
+		To:     to.Format(models.Layout),
+		Done:   []models.PeriodCount{},
+		Tasks:  []models.TaskStats{},
+	}
+
+	buckets := make(map[string]int)
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		key := periodKey(day, period)
+		if _, ok := buckets[key]; !ok {
+			buckets[key] = 0
+			stats.Done = append(stats.Done, models.PeriodCount{Period: key})
+		}
+	}
+
+	today := now.Format(models.Layout)
+	perTask := make(map[string]*models.TaskStats)
+	current := make(map[string]int)
+	run := make(map[string]int)
+	var order []string
+	for _, c := range completions {
+		doneAt, err := time.Parse(time.RFC3339, c.DoneAt)
+		if err != nil {
+			return models.Stats{}, fmt.Errorf("invalid completion time: %s", c.DoneAt)
+		}
+		doneDay := doneAt.In(now.Location()).Format(models.Layout)
+		if doneDay > today {
+			continue
+		}
+		onTime := doneDay <= c.Date
+		if onTime {
+			current[c.TaskId]++
+		} else {
+			current[c.TaskId] = 0
+		}
+		if doneDay < stats.From || doneDay > stats.To {
+			continue
+		}
+
+		ts, ok := perTask[c.TaskId]
+		if !ok {
+			ts = &models.TaskStats{TaskId: c.TaskId}
+			perTask[c.TaskId] = ts
+			order = append(order, c.TaskId)
+		}
+		ts.Title = c.Title
+		ts.Completed++
+		ts.LastDone = c.DoneAt
+		if onTime {
+			ts.OnTime++
+			run[c.TaskId]++
+			ts.BestStreak = max(ts.BestStreak, run[c.TaskId])
+		} else {
+			ts.Late++
+			run[c.TaskId] = 0
+		}
+
+		day, _ := time.ParseInLocation(models.Layout, doneDay, now.Location())
+		buckets[periodKey(day, period)]++
+	}
+
+	for i := range stats.Done {
+		stats.Done[i].Count = buckets[stats.Done[i].Period]
+	}
+
+	for _, id := range order {
+		ts := perTask[id]
+		ts.CurrentStreak = current[id]
+		ts.OnTimeRate = rate(ts.OnTime, ts.Completed)
+		stats.Totals.Completed += ts.Completed
+		stats.Totals.OnTime += ts.OnTime
+		stats.Totals.Late += ts.Late
+		stats.Tasks = append(stats.Tasks, *ts)
+	}
+	sort.SliceStable(stats.Tasks, func(i, j int) bool {
+		return stats.Tasks[i].CurrentStreak > stats.Tasks[j].CurrentStreak
+	})
+	stats.Totals.OnTimeRate = rate(stats.Totals.OnTime, stats.Totals.Completed)
+
+	for _, task := range tasks {
+		stats.Totals.Open++
+		if IsOverdue(task, today) {
+			stats.Totals.Overdue++
+		}
+	}
+	return stats, nil
+}
+
+// IsOverdue reports whether a one-off task was due before today. Recurring
+// tasks are not counted as overdue.
+func IsOverdue(task models.Task, today string) bool {
+	return task.Repeat == "" && task.Date < today
+}
+
+func periodKey(day time.Time, period string) string {
+	if period == PeriodWeek {
+		year, week := day.ISOWeek()
+		return fmt.Sprintf("%04d-W%02d", year, week)
+	}
+	return day.Format(models.Layout)
+}
+
+func rate(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(part) / float64(total)
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+// maxEstimate is the longest estimate of a task in minutes, a full day.
+const maxEstimate = 24 * 60
+
+// CheckTask validates task and normalizes its date: an empty date means today,
+// and a date in the past is moved to today or to the next repeat occurrence.
+func CheckTask(task *models.Task, now time.Time) error {
+	if task.Title == "" {
+		return errors.New("task title is required")
+	}
+	tags, err := NormalizeTags(task.Tags)
+	if err != nil {
+		return err
+	}
+	task.Tags = tags
+
+	if task.Time != "" {
+		at, err := time.Parse(models.TimeLayout, task.Time)
+		if err != nil {
+			return fmt.Errorf("invalid time: %s", task.Time)
+		}
+		task.Time = at.Format(models.TimeLayout)
+	}
+	if task.Estimate < 0 || task.Estimate > maxEstimate {
+		return fmt.Errorf("estimate must be between 0 and %d minutes", maxEstimate)
+	}
+
+	switch {
+	case task.Repeat == "":
+		task.RepeatFrom = ""
+	case task.RepeatFrom != "" && task.RepeatFrom != models.RepeatFromCompletion:
+		return fmt.Errorf("invalid repeat_from: %s", task.RepeatFrom)
+	}
+
+	today := now.Format(models.Layout)
+	if task.Date == "" {
+		task.Date = today
+	}
+
+	date, err := stringToTime(task.Date)
+	if err != nil {
+		return fmt.Errorf("invalid date: %s", task.Date)
+	}
+
+	if task.Repeat != "" {
+		next, err := NextDate(today, task.Date, task.Repeat)
+		if err != nil {
+			return err
+		}
+		if next == "" {
+			return fmt.Errorf("invalid repeat code: %s", task.Repeat)
+		}
+		if task.Date < today {
+			task.Date = next
+		}
+		return nil
+	}
+
+	if date.Format(models.Layout) < today {
+		task.Date = today
+	}
+	return nil
+}
+
+// NormalizeTags lower-cases tags, strips a leading # and drops duplicates.
+func NormalizeTags(tags []string) ([]string, error) {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+		if tag == "" || strings.ContainsAny(tag, ", \t\n") {
+			return nil, fmt.Errorf("invalid tag: %q", tag)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			normalized = append(normalized, tag)
+		}
+	}
+	return normalized, nil
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/paran0iaa/TODO/internal/models"
+)
+
+var templateVar = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// FillTemplate returns the task and the checklist of t with each {{name}}
+// replaced by vars[name]. Every variable the template uses must be given, and
+// no checklist item may end up empty.
+func FillTemplate(t models.Template, vars map[string]string) (models.Task, []string, error) {
+	missing := make(map[string]bool)
+	fill := func(text string) string {
+		return templateVar.ReplaceAllStringFunc(text, func(m string) string {
+			name := templateVar.FindStringSubmatch(m)[1]
+			value, ok := vars[name]
+			if !ok {
+				missing[name] = true
+			}
+			return value
+		})
+	}
+
+	task := models.Task{Title: fill(t.Title), Comment: fill(t.Comment), Repeat: t.Repeat}
+	for _, tag := range t.Tags {
+		task.Tags = append(task.Tags, fill(tag))
+	}
+	checklist := make([]string, len(t.Checklist))
+	for i, item := range t.Checklist {
+		checklist[i] = fill(item)
+	}
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return models.Task{}, nil, fmt.Errorf("missing template variables: %s", strings.Join(names, ", "))
+	}
+	for i, item := range checklist {
+		if strings.TrimSpace(item) == "" {
+			return models.Task{}, nil, fmt.Errorf("checklist item %d is empty", i+1)
+		}
+	}
+	return task, checklist, nil
+}
@@ -0,0 +1,149 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type auditEntry struct {
+	Action  string                    `json:"action"`
+	TaskID  string                    `json:"task_id"`
+	Changes map[string]map[string]any `json:"changes"`
+}
+
+func TestAudit(t *testing.T) {
+	date := time.Now().AddDate(0, 0, 1).Format(`20060102`)
+	id := addTask(t, task{date: date, title: "Аудит"})
+
+	ret, err := postJSON("api/task", map[string]any{"id": id, "date": date, "title": "Аудит изменён"}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	ret, err = postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+
+	body, err := requestJSON("api/audit?task_id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	var m map[string][]auditEntry
+	assert.NoError(t, json.Unmarshal(body, &m))
+
+	entries := m["entries"]
+	if assert.Len(t, entries, 3) {
+		assert.Equal(t, "delete", entries[0].Action)
+		assert.Equal(t, "update", entries[1].Action)
+		assert.Equal(t, "create", entries[2].Action)
+		assert.Equal(t, map[string]any{"old": "Аудит", "new": "Аудит изменён"}, entries[1].Changes["title"])
+		assert.NotContains(t, entries[1].Changes, "date")
+	}
+
+	body, err = requestJSON("api/audit?action=update&task_id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(body, &m))
+	assert.Len(t, m["entries"], 1)
+}
+
+func auditLog(t *testing.T, id string) []auditEntry {
+	body, err := requestJSON("api/audit?task_id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	var m map[string][]auditEntry
+	assert.NoError(t, json.Unmarshal(body, &m))
+	return m["entries"]
+}
+
+func TestAuditExceptionsAndBulk(t *testing.T) {
+	id := addTask(t, task{date: day(1), title: "Аудит повторений", repeat: "d 7"})
+
+	// Исключение для не текущего повторения тоже попадает в журнал.
+	ret, err := postJSON("api/task/exceptions?id="+id, map[string]any{"date": day(8)}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+	ret, err = postJSON("api/task/exceptions?id="+id+"&date="+day(8), nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	ret, err = postJSON("api/task/snooze?id="+id+"&by=2d", nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+
+	entries := auditLog(t, id)
+	if assert.Len(t, entries, 4) {
+		assert.Equal(t, "update", entries[0].Action)
+		assert.Equal(t, map[string]any{"old": "", "new": day(1)}, entries[0].Changes["anchor"])
+		assert.Equal(t, "update", entries[1].Action)
+		assert.Equal(t, []any{map[string]any{"date": day(8)}}, entries[1].Changes["exceptions"]["old"])
+		assert.Nil(t, entries[1].Changes["exceptions"]["new"])
+		assert.Equal(t, "update", entries[2].Action)
+		assert.Equal(t, []any{map[string]any{"date": day(8)}}, entries[2].Changes["exceptions"]["new"])
+		assert.Equal(t, "create", entries[3].Action)
+	}
+
+	ret, err = postJSON("api/tasks/bulk", map[string]any{
+		"operations": []map[string]any{
+			{"op": "reschedule", "id": id, "days": 1},
+			{"op": "delete", "id": id},
+		},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+	entries = auditLog(t, id)
+	if assert.Len(t, entries, 6) {
+		assert.Equal(t, "delete", entries[0].Action)
+		assert.Equal(t, "update", entries[1].Action)
+	}
+}
+
+func TestAuditSideTables(t *testing.T) {
+	id := addTask(t, task{date: day(1), title: "Аудит чек-листа"})
+	other := addTask(t, task{date: day(1), title: "Аудит зависимости"})
+
+	var item map[string]string
+	body, err := requestJSON("api/task/checklist?id="+id, map[string]any{"title": "Пункт"}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(body, &item))
+	for _, req := range []struct {
+		url    string
+		body   map[string]any
+		method string
+	}{
+		{"api/task/checklist/done?id=" + item["id"], nil, http.MethodPost},
+		{"api/task/checklist?id=" + item["id"], nil, http.MethodDelete},
+		{"api/task/dependencies?id=" + id, map[string]any{"depends_on": other}, http.MethodPost},
+		{"api/task/dependencies?id=" + id + "&depends_on=" + other, nil, http.MethodDelete},
+		{"api/task/reminder?id=" + id, map[string]any{"at": "09:00", "channel": "webhook",
+			"target": "http://localhost:1/hook"}, http.MethodPut},
+		{"api/task/reminder?id=" + id, nil, http.MethodDelete},
+	} {
+		ret, err := postJSON(req.url, req.body, req.method)
+		assert.NoError(t, err)
+		assert.Nil(t, ret["error"], req.url)
+	}
+
+	entries := auditLog(t, id)
+	if assert.Len(t, entries, 8) {
+		for _, entry := range entries[:7] {
+			assert.Equal(t, "update", entry.Action)
+		}
+		assert.Equal(t, "create", entries[7].Action)
+	}
+}
+
+func TestAuditPurge(t *testing.T) {
+	id := addTask(t, task{date: day(1), title: "Аудит корзины"})
+
+	ret, err := postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	ret, err = postJSON("api/trash?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+
+	entries := auditLog(t, id)
+	if assert.Len(t, entries, 3) {
+		assert.Equal(t, "purge", entries[0].Action)
+		assert.Equal(t, map[string]any{"old": "Аудит корзины", "new": nil}, entries[0].Changes["title"])
+		assert.Equal(t, "delete", entries[1].Action)
+	}
+}
@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func signIn(t *testing.T, password string) (int, string) {
+	data, err := json.Marshal(map[string]string{"password": password})
+	require.NoError(t, err)
+	resp, err := http.Post(getURL("api/signin"), "application/json", bytes.NewReader(data))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var m map[string]string
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&m))
+	return resp.StatusCode, m["token"]
+}
+
+func authStatus(t *testing.T, header, cookie string) int {
+	req, err := http.NewRequest(http.MethodGet, getURL("api/tasks"), nil)
+	require.NoError(t, err)
+	if header != "" {
+		req.Header.Set("Authorization", header)
+	}
+	if cookie != "" {
+		req.AddCookie(&http.Cookie{Name: "token", Value: cookie})
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+// Сервер для этого теста запускается с TODO_PASSWORD, а для проверки
+// просроченного токена — ещё и с TODO_SECRET.
+func TestSignIn(t *testing.T) {
+	password := os.Getenv("TODO_PASSWORD")
+	if password == "" {
+		t.Skip("TODO_PASSWORD is not set")
+	}
+
+	code, token := signIn(t, password+"!")
+	assert.Equal(t, http.StatusUnauthorized, code)
+	assert.Empty(t, token)
+
+	code, token = signIn(t, password)
+	require.Equal(t, http.StatusOK, code)
+	require.NotEmpty(t, token)
+
+	assert.Equal(t, http.StatusUnauthorized, authStatus(t, "", ""))
+	assert.Equal(t, http.StatusOK, authStatus(t, "Bearer "+token, ""))
+	assert.Equal(t, http.StatusOK, authStatus(t, "", token))
+	assert.Equal(t, http.StatusUnauthorized, authStatus(t, token, ""), "Токен без Bearer не принимается")
+	assert.Equal(t, http.StatusOK, authStatus(t, "Basic YWRtaW46YWRtaW4=", token))
+	assert.Equal(t, http.StatusUnauthorized, authStatus(t, "Bearer "+token+"x", token))
+
+	secret := os.Getenv("TODO_SECRET")
+	if secret == "" {
+		return
+	}
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." +
+		enc.EncodeToString([]byte(fmt.Sprintf(`{"sub":"admin","exp":%d}`, time.Now().Add(-time.Minute).Unix())))
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	expired := unsigned + "." + enc.EncodeToString(mac.Sum(nil))
+	assert.Equal(t, http.StatusUnauthorized, authStatus(t, "Bearer "+expired, ""))
+	assert.Equal(t, http.StatusUnauthorized, authStatus(t, "", expired))
+}
@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulk(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	date := now.AddDate(0, 0, 1).Format(`20060102`)
+	moved := addTask(t, task{date: date, title: "Перенести"})
+	done := addTask(t, task{date: date, title: "Выполнить"})
+
+	ret, err := postJSON("api/tasks/bulk", map[string]any{
+		"operations": []map[string]any{
+			{"op": "reschedule", "id": moved, "days": 40},
+			{"op": "done", "id": done},
+			{"op": "delete", "id": "999999999"},
+		},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	// Nothing is applied when one operation fails.
+	var count int
+	assert.NoError(t, db.Get(&count, `SELECT count(*) FROM scheduler WHERE id IN (?, ?) AND date = ?`, moved, done, date))
+	assert.Equal(t, 2, count)
+
+	ret, err = postJSON("api/tasks/bulk", map[string]any{
+		"operations": []map[string]any{
+			{"op": "reschedule", "id": moved, "days": 40},
+			{"op": "done", "id": done},
+		},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+	assert.Len(t, ret["results"], 2)
+
+	var newDate string
+	assert.NoError(t, db.Get(&newDate, `SELECT date FROM scheduler WHERE id = ?`, moved))
+	assert.Equal(t, now.AddDate(0, 0, 41).Format(`20060102`), newDate)
+	assert.NoError(t, db.Get(&count, `SELECT count(*) FROM scheduler WHERE id = ?`, done))
+	assert.Equal(t, 0, count)
+
+	// A task moved into the past lands on today, as in PUT.
+	ret, err = postJSON("api/tasks/bulk", map[string]any{
+		"operations": []map[string]any{{"op": "reschedule", "id": moved, "days": -60}},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+	assert.NoError(t, db.Get(&newDate, `SELECT date FROM scheduler WHERE id = ?`, moved))
+	assert.Equal(t, now.Format(`20060102`), newDate)
+}
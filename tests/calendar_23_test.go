@@ -0,0 +1,83 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendar(t *testing.T) {
+	tags := []string{"calendar"}
+	weekly := addTaskFields(t, map[string]any{"date": day(1), "title": "Планёрка", "repeat": "d 7", "tags": tags})
+	oneOff := addTaskFields(t, map[string]any{"date": day(3), "title": "Сдать отчёт", "repeat": "", "tags": tags})
+	ret, err := postJSON("api/task/exceptions?id="+weekly, map[string]any{"date": day(8)}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+	// Повторение после конца диапазона, перенесённое внутрь него.
+	ret, err = postJSON("api/task/exceptions?id="+weekly, map[string]any{"date": day(22), "moved_to": day(5)}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+
+	body, err := requestJSON("api/calendar?q=tag:calendar&from="+day(0)+"&to="+day(20), nil, http.MethodGet)
+	assert.NoError(t, err)
+	var m map[string][]struct {
+		Date      string         `json:"date"`
+		Scheduled string         `json:"scheduled"`
+		Task      map[string]any `json:"task"`
+	}
+	assert.NoError(t, json.Unmarshal(body, &m))
+
+	var got [][2]string
+	for _, v := range m["occurrences"] {
+		got = append(got, [2]string{v.Date, v.Task["id"].(string)})
+	}
+	assert.Equal(t, [][2]string{
+		{day(1), weekly}, {day(3), oneOff}, {day(5), weekly}, {day(15), weekly},
+	}, got)
+
+	body, err = requestJSON("api/calendar?q=tag:calendar&from="+day(2)+"&to="+day(14), nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(body, &m))
+	assert.Len(t, m["occurrences"], 2)
+
+	for _, query := range []string{
+		"",
+		"from=" + day(0),
+		"from=" + day(5) + "&to=" + day(1),
+		"from=" + day(0) + "&to=" + day(400),
+		"from=2024&to=" + day(1),
+	} {
+		ret, err = postJSON("api/calendar?"+query, nil, http.MethodGet)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], query)
+	}
+}
+
+func TestCalendarFarAhead(t *testing.T) {
+	weekly := addTask(t, task{date: day(1), title: "Далёкая планёрка", repeat: "d 7"})
+	addTask(t, task{date: day(1), title: "Далёкая зарядка", repeat: "d 1"})
+
+	// Через четыре года: серия шагает сразу к началу диапазона.
+	from := 1 + 7*200
+	body, err := requestJSON("api/calendar?q=title:Далёк*&from="+day(from)+"&to="+day(from+13), nil, http.MethodGet)
+	assert.NoError(t, err)
+	var m map[string][]struct {
+		Date string         `json:"date"`
+		Task map[string]any `json:"task"`
+	}
+	assert.NoError(t, json.Unmarshal(body, &m))
+	assert.Len(t, m["occurrences"], 16)
+	var dates []string
+	for _, v := range m["occurrences"] {
+		if v.Task["id"] == weekly {
+			dates = append(dates, v.Date)
+		}
+	}
+	assert.Equal(t, []string{day(from), day(from + 7)}, dates)
+
+	ret, err := postJSON("api/calendar?from="+day(6*366)+"&to="+day(6*366+1), nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+}
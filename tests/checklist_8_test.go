@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type checklistItem struct {
+	ID    string `json:"id"`
+	Title string `json:"title"`
+	Done  bool   `json:"done"`
+}
+
+func getChecklist(t *testing.T, id string) []checklistItem {
+	body, err := requestJSON("api/task/checklist?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+
+	var m map[string][]checklistItem
+	err = json.Unmarshal(body, &m)
+	assert.NoError(t, err)
+	return m["items"]
+}
+
+func TestChecklist(t *testing.T) {
+	id := addTask(t, task{
+		title:  "Релиз",
+		repeat: "d 14",
+	})
+
+	for _, title := range []string{"Заморозить ветку", "Собрать сборку", "Выложить"} {
+		ret, err := postJSON("api/task/checklist?id="+id, map[string]any{
+			"title": title,
+		}, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, fmt.Sprint(ret["id"]))
+	}
+
+	items := getChecklist(t, id)
+	assert.Len(t, items, 3)
+	assert.Equal(t, "Заморозить ветку", items[0].Title)
+	assert.Equal(t, "Выложить", items[2].Title)
+
+	ret, err := postJSON("api/task/checklist/done?id="+items[1].ID, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	items = getChecklist(t, id)
+	assert.False(t, items[0].Done)
+	assert.True(t, items[1].Done)
+
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	for _, item := range getChecklist(t, id) {
+		assert.False(t, item.Done, "Чек-лист должен сбрасываться для следующего повторения")
+	}
+
+	ret, err = postJSON("api/task/checklist?id="+id, map[string]any{}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+}
@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func addDependency(t *testing.T, id, dependsOn string) map[string]any {
+	ret, err := postJSON("api/task/dependencies?id="+id, map[string]any{
+		"depends_on": dependsOn,
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	return ret
+}
+
+func readyIDs(t *testing.T) map[string]bool {
+	body, err := requestJSON("api/tasks?ready=true", nil, http.MethodGet)
+	assert.NoError(t, err)
+
+	var m map[string][]map[string]any
+	assert.NoError(t, json.Unmarshal(body, &m))
+	ids := make(map[string]bool)
+	for _, v := range m["tasks"] {
+		ids[v["id"].(string)] = true
+	}
+	return ids
+}
+
+func TestDependencies(t *testing.T) {
+	backup := addTask(t, task{title: "Сделать бэкап"})
+	migrate := addTask(t, task{title: "Мигрировать базу"})
+	check := addTask(t, task{title: "Проверить данные"})
+
+	assert.Empty(t, addDependency(t, migrate, backup))
+	assert.Empty(t, addDependency(t, check, migrate))
+	assert.NotEmpty(t, addDependency(t, backup, check)["error"], "Ожидается ошибка для цикла")
+	assert.NotEmpty(t, addDependency(t, backup, backup)["error"], "Ожидается ошибка для цикла")
+
+	ready := readyIDs(t)
+	assert.True(t, ready[backup])
+	assert.False(t, ready[migrate])
+	assert.False(t, ready[check])
+
+	ret, err := postJSON("api/task/done?id="+backup, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+
+	ready = readyIDs(t)
+	assert.True(t, ready[migrate])
+	assert.False(t, ready[check])
+}
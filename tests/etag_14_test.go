@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// requestIfMatch returns the status and the ETag of the response.
+func requestIfMatch(t *testing.T, method, apipath, etag string, values map[string]any) (int, string) {
+	data, err := json.Marshal(values)
+	assert.NoError(t, err)
+	req, err := http.NewRequest(method, getURL(apipath), bytes.NewBuffer(data))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("If-Match", etag)
+	if len(Token) > 0 {
+		req.AddCookie(&http.Cookie{Name: "token", Value: Token})
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode, resp.Header.Get("ETag")
+}
+
+func taskETag(t *testing.T, id string) string {
+	req, err := http.NewRequest(http.MethodGet, getURL("api/task?id="+id), nil)
+	assert.NoError(t, err)
+	if len(Token) > 0 {
+		req.AddCookie(&http.Cookie{Name: "token", Value: Token})
+	}
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	return resp.Header.Get("ETag")
+}
+
+func TestETag(t *testing.T) {
+	date := time.Now().AddDate(0, 0, 1).Format(`20060102`)
+	id := addTask(t, task{date: date, title: "Версия"})
+
+	etag := taskETag(t, id)
+	assert.NotEmpty(t, etag)
+
+	update := map[string]any{"id": id, "date": date, "title": "Первая правка"}
+	status, updated := requestIfMatch(t, http.MethodPut, "api/task", etag, update)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, taskETag(t, id), updated)
+	assert.NotEqual(t, etag, updated)
+
+	// The second teammate still holds the old ETag.
+	update["title"] = "Вторая правка"
+	status, _ = requestIfMatch(t, http.MethodPut, "api/task", etag, update)
+	assert.Equal(t, http.StatusPreconditionFailed, status)
+	status, _ = requestIfMatch(t, http.MethodDelete, "api/task?id="+id, etag, nil)
+	assert.Equal(t, http.StatusPreconditionFailed, status)
+
+	status, _ = requestIfMatch(t, http.MethodDelete, "api/task?id="+id, updated, nil)
+	assert.Equal(t, http.StatusOK, status)
+}
+
+func TestBulkETag(t *testing.T) {
+	date := time.Now().AddDate(0, 0, 1).Format(`20060102`)
+	id := addTask(t, task{date: date, title: "Версия в пакете"})
+	etag := taskETag(t, id)
+
+	ret, err := postJSON("api/task", map[string]any{"id": id, "date": date, "title": "Правка"}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+
+	status, _ := requestIfMatch(t, http.MethodPost, "api/tasks/bulk", "", map[string]any{
+		"operations": []map[string]any{{"op": "reschedule", "id": id, "days": 1, "if_match": etag}},
+	})
+	assert.Equal(t, http.StatusPreconditionFailed, status)
+
+	ret, err = postJSON("api/tasks/bulk", map[string]any{
+		"operations": []map[string]any{{"op": "delete", "id": id, "if_match": taskETag(t, id)}},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+}
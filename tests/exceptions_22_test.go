@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type occurrence struct {
+	Date      string `json:"date"`
+	Scheduled string `json:"scheduled"`
+}
+
+func getOccurrences(t *testing.T, id string) []occurrence {
+	body, err := requestJSON("api/task/occurrences?id="+id+"&count=4", nil, http.MethodGet)
+	assert.NoError(t, err)
+
+	var m map[string][]occurrence
+	assert.NoError(t, json.Unmarshal(body, &m))
+	return m["occurrences"]
+}
+
+func TestExceptions(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	id := addTask(t, task{date: day(1), title: "Планёрка", repeat: "d 7"})
+	assert.Equal(t, []occurrence{
+		{day(1), day(1)}, {day(8), day(8)}, {day(15), day(15)}, {day(22), day(22)},
+	}, getOccurrences(t, id))
+
+	// Праздник отменяет одну планёрку, другую переносят на день.
+	ret, err := postJSON("api/task/exceptions?id="+id, map[string]any{"date": day(8)}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+	assert.Equal(t, day(1), ret["date"])
+	ret, err = postJSON("api/task/exceptions?id="+id, map[string]any{"date": day(15), "moved_to": day(16)}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+	assert.Equal(t, []occurrence{
+		{day(1), day(1)}, {day(16), day(15)}, {day(22), day(22)}, {day(29), day(29)},
+	}, getOccurrences(t, id))
+
+	var row Task
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	assert.NoError(t, db.Get(&row, `SELECT * FROM scheduler WHERE id=?`, id))
+	assert.Equal(t, day(16), row.Date)
+
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	assert.NoError(t, db.Get(&row, `SELECT * FROM scheduler WHERE id=?`, id))
+	assert.Equal(t, day(22), row.Date, "Правило повторения не меняется")
+
+	// Исключение для текущего повторения применяется сразу.
+	ret, err = postJSON("api/task/exceptions?id="+id, map[string]any{"date": day(22)}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+	assert.NoError(t, db.Get(&row, `SELECT * FROM scheduler WHERE id=?`, id))
+	assert.Equal(t, day(29), row.Date)
+
+	for _, e := range []map[string]any{
+		{"date": day(30)},
+		{"date": day(15)},
+		{"date": "2024"},
+		{"date": day(36), "moved_to": day(36)},
+		{"date": "99991231"},
+		{"date": day(36), "moved_to": day(400)},
+		{"date": day(36), "moved_to": day(-1)},
+	} {
+		ret, err = postJSON("api/task/exceptions?id="+id, e, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], e)
+	}
+
+	ret, err = postJSON("api/task/exceptions?id="+id+"&date="+day(8), nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	ret, err = postJSON("api/task/exceptions?id="+id+"&date="+day(8), nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+
+	oneOff := addTask(t, task{date: day(1), title: "Разовая задача"})
+	ret, err = postJSON("api/task/exceptions?id="+oneOff, map[string]any{"date": day(1)}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+}
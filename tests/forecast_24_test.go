@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type forecastDay struct {
+	Date       string `json:"date"`
+	Tasks      int    `json:"tasks"`
+	Minutes    int    `json:"minutes"`
+	Overloaded bool   `json:"overloaded"`
+	Overlaps   []struct {
+		Tasks []string `json:"tasks"`
+		From  string   `json:"from"`
+		To    string   `json:"to"`
+	} `json:"overlaps"`
+}
+
+func TestForecast(t *testing.T) {
+	now := time.Now()
+	tags := []string{"forecast"}
+	first := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.Local).Format(`20060102`)
+
+	for _, title := range []string{"Отчёт по продажам", "Отчёт по расходам", "Отчёт для инвесторов"} {
+		addTaskFields(t, map[string]any{"date": first, "title": title, "repeat": "m 1", "estimate": 120, "tags": tags})
+	}
+	call := addTaskFields(t, map[string]any{"date": day(2), "title": "Созвон", "time": "10:00", "estimate": 60, "tags": tags})
+	review := addTaskFields(t, map[string]any{"date": day(2), "title": "Ревью", "time": "10:30", "estimate": 30, "tags": tags})
+
+	body, err := requestJSON("api/forecast?q=tag:forecast&capacity=300&from="+day(1)+"&to="+day(40), nil, http.MethodGet)
+	assert.NoError(t, err)
+	var m map[string][]forecastDay
+	assert.NoError(t, json.Unmarshal(body, &m))
+	assert.Len(t, m["days"], 40)
+
+	days := make(map[string]forecastDay)
+	for _, d := range m["days"] {
+		days[d.Date] = d
+	}
+	assert.Equal(t, 3, days[first].Tasks)
+	assert.Equal(t, 360, days[first].Minutes)
+	assert.True(t, days[first].Overloaded, "Три отчёта первого числа не помещаются в день")
+
+	d := days[day(2)]
+	assert.Equal(t, 2, d.Tasks)
+	assert.Equal(t, 90, d.Minutes)
+	assert.False(t, d.Overloaded)
+	if assert.Len(t, d.Overlaps, 1) {
+		assert.ElementsMatch(t, []string{call, review}, d.Overlaps[0].Tasks)
+		assert.Equal(t, "10:30", d.Overlaps[0].From)
+		assert.Equal(t, "11:00", d.Overlaps[0].To)
+	}
+	assert.Zero(t, days[day(3)].Tasks)
+
+	body, err = requestJSON("api/forecast?q=tag:forecast&max_tasks=1&capacity=0&from="+day(2)+"&to="+day(2), nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(body, &m))
+	if assert.Len(t, m["days"], 1) {
+		assert.True(t, m["days"][0].Overloaded)
+	}
+
+	for _, query := range []string{
+		"from=" + day(1),
+		"from=" + day(1) + "&to=" + day(2) + "&capacity=-5",
+		"from=" + day(1) + "&to=" + day(2) + "&max_tasks=many",
+	} {
+		ret, err := postJSON("api/forecast?"+query, nil, http.MethodGet)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], query)
+	}
+	for _, task := range []map[string]any{
+		{"title": "Плохое время", "time": "25:00"},
+		{"title": "Плохая оценка", "estimate": -10},
+	} {
+		ret, err := postJSON("api/task", task, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], task)
+	}
+}
+
+func TestForecastPastMidnight(t *testing.T) {
+	tags := []string{"midnight"}
+	late := addTaskFields(t, map[string]any{"date": day(5), "title": "Релиз", "time": "23:00", "estimate": 120, "tags": tags})
+	night := addTaskFields(t, map[string]any{"date": day(5), "title": "Бэкап", "time": "23:30", "estimate": 60, "tags": tags})
+	early := addTaskFields(t, map[string]any{"date": day(6), "title": "Проверка", "time": "00:15", "estimate": 30, "tags": tags})
+
+	// Ночь начинается до from, но пересечения после полуночи всё равно видны.
+	body, err := requestJSON("api/forecast?q=tag:midnight&from="+day(5)+"&to="+day(6), nil, http.MethodGet)
+	assert.NoError(t, err)
+	var m map[string][]forecastDay
+	assert.NoError(t, json.Unmarshal(body, &m))
+	require.Len(t, m["days"], 2)
+
+	type overlap struct {
+		tasks    []string
+		from, to string
+	}
+	got := func(d forecastDay) []overlap {
+		var res []overlap
+		for _, o := range d.Overlaps {
+			res = append(res, overlap{o.Tasks, o.From, o.To})
+		}
+		return res
+	}
+	assert.Equal(t, []overlap{{[]string{late, night}, "23:30", "24:00"}}, got(m["days"][0]))
+	assert.Equal(t, 180, m["days"][0].Minutes)
+	assert.ElementsMatch(t, []overlap{
+		{[]string{late, night}, "00:00", "00:30"},
+		{[]string{late, early}, "00:15", "00:45"},
+		{[]string{night, early}, "00:15", "00:30"},
+	}, got(m["days"][1]))
+	assert.Equal(t, 30, m["days"][1].Minutes)
+
+	body, err = requestJSON("api/forecast?q=tag:midnight&from="+day(6)+"&to="+day(6), nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(body, &m))
+	require.Len(t, m["days"], 1)
+	assert.Len(t, m["days"][0].Overlaps, 3)
+	assert.Equal(t, 1, m["days"][0].Tasks)
+}
@@ -0,0 +1,45 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory(t *testing.T) {
+	now := time.Now()
+	id := addTask(t, task{
+		date:   now.Format(`20060102`),
+		title:  "Проверить бэкапы",
+		repeat: "d 7",
+	})
+
+	ret, err := postJSON("api/task/done?id="+id, map[string]any{
+		"note": "Восстановление прошло успешно",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+
+	body, err := requestJSON("api/task/history?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	var m map[string][]map[string]string
+	err = json.Unmarshal(body, &m)
+	assert.NoError(t, err)
+
+	history := m["history"]
+	assert.Len(t, history, 2)
+	if len(history) != 2 {
+		return
+	}
+	assert.Equal(t, now.AddDate(0, 0, 7).Format(`20060102`), history[0]["date"])
+	assert.Equal(t, now.Format(`20060102`), history[1]["date"])
+	assert.Equal(t, "Восстановление прошло успешно", history[1]["note"])
+	_, err = time.Parse(time.RFC3339, history[0]["done_at"])
+	assert.NoError(t, err)
+}
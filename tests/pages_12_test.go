@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tasksPage struct {
+	Tasks []struct {
+		ID string `json:"id"`
+	} `json:"tasks"`
+	NextCursor string `json:"next_cursor"`
+}
+
+func getPage(t *testing.T, sort, cursor string) tasksPage {
+	params := url.Values{"limit": {"2"}, "sort": {sort}, "cursor": {cursor}}
+	body, err := requestJSON("api/tasks?"+params.Encode(), nil, http.MethodGet)
+	assert.NoError(t, err)
+
+	var page tasksPage
+	assert.NoError(t, json.Unmarshal(body, &page))
+	return page
+}
+
+func TestPages(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	_, err := db.Exec("DELETE FROM scheduler")
+	assert.NoError(t, err)
+
+	now := time.Now()
+	var ids []string
+	for i := 0; i < 5; i++ {
+		// Two tasks share each date, so pages split ties by id.
+		ids = append(ids, addTask(t, task{date: now.AddDate(0, 0, i/2).Format(`20060102`), title: "Страница"}))
+	}
+
+	for _, sort := range []string{"date", "-date"} {
+		var got []string
+		cursor := ""
+		for pages := 0; pages < 5; pages++ {
+			page := getPage(t, sort, cursor)
+			assert.LessOrEqual(t, len(page.Tasks), 2)
+			for _, task := range page.Tasks {
+				got = append(got, task.ID)
+			}
+			if page.NextCursor == "" {
+				break
+			}
+			cursor = page.NextCursor
+		}
+
+		want := ids
+		if sort == "-date" {
+			want = []string{ids[4], ids[3], ids[2], ids[1], ids[0]}
+		}
+		assert.Equal(t, want, got, sort)
+	}
+
+	ret, err := postJSON("api/tasks?sort=date&cursor="+getPage(t, "-date", "").NextCursor, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+}
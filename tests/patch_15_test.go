@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatchTask(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	date := time.Now().AddDate(0, 0, 2).Format(`20060102`)
+	id := addTask(t, task{date: date, title: "Заголовок", comment: "Комментарий", repeat: "d 5"})
+
+	ret, err := postJSON("api/task?id="+id, map[string]any{"title": "Новый заголовок"}, http.MethodPatch)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+
+	var task Task
+	assert.NoError(t, db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id))
+	assert.Equal(t, "Новый заголовок", task.Title)
+	assert.Equal(t, "Комментарий", task.Comment)
+	assert.Equal(t, "d 5", task.Repeat)
+	assert.Equal(t, date, task.Date)
+
+	ret, err = postJSON("api/task?id="+id, map[string]any{"comment": nil}, http.MethodPatch)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+	assert.NoError(t, db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id))
+	assert.Empty(t, task.Comment)
+	assert.Equal(t, "Новый заголовок", task.Title)
+
+	for _, patch := range []map[string]any{
+		{"title": nil},
+		{"date": "20240192"},
+		{"repeat": "ooops"},
+		{"anchor": "20240101"},
+		{"exceptions": []any{}},
+		{"blocked": true},
+	} {
+		ret, err = postJSON("api/task?id="+id, patch, http.MethodPatch)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], patch)
+	}
+}
+
+func TestPatchETag(t *testing.T) {
+	id := addTask(t, task{date: time.Now().AddDate(0, 0, 2).Format(`20060102`), title: "Версия патча"})
+
+	// The ETag of a PATCH response is good for the next change.
+	status, etag := requestIfMatch(t, http.MethodPatch, "api/task?id="+id, taskETag(t, id), map[string]any{"comment": "Правка"})
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, taskETag(t, id), etag)
+	status, _ = requestIfMatch(t, http.MethodDelete, "api/task?id="+id, etag, nil)
+	assert.Equal(t, http.StatusOK, status)
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addTaskFields creates a task from any fields of the API, such as tags,
+// time or estimate, and returns its id.
+func addTaskFields(t *testing.T, fields map[string]any) string {
+	ret, err := postJSON("api/task", fields, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"], fields)
+	id, _ := ret["id"].(string)
+	assert.NotEmpty(t, id)
+	return id
+}
+
+func queryIDs(t *testing.T, params string) map[string]bool {
+	body, err := requestJSON("api/tasks?"+params, nil, http.MethodGet)
+	require.NoError(t, err)
+
+	var m map[string]any
+	require.NoError(t, json.Unmarshal(body, &m))
+	require.Nil(t, m["error"], params)
+	ids := make(map[string]bool)
+	for _, v := range m["tasks"].([]any) {
+		ids[v.(map[string]any)["id"].(string)] = true
+	}
+	return ids
+}
+
+func TestQuery(t *testing.T) {
+	release := addTaskFields(t, map[string]any{"date": day(2), "title": "Выпустить релиз", "repeat": "",
+		"tags": []string{"q26", "release"}})
+	deploy := addTaskFields(t, map[string]any{"date": day(10), "title": "Deploy staging", "repeat": "d 7",
+		"tags": []string{"q26", "release"}})
+	milk := addTaskFields(t, map[string]any{"date": day(1), "title": "Купить молоко", "repeat": "",
+		"tags": []string{"q26"}})
+
+	q := func(query string) map[string]bool {
+		return queryIDs(t, "q="+url.QueryEscape(query))
+	}
+	assert.Equal(t, map[string]bool{release: true, deploy: true}, q("tag:q26 tag:release"))
+	assert.Equal(t, map[string]bool{release: true, milk: true}, q("tag:q26 due<today+5"))
+	assert.Equal(t, map[string]bool{deploy: true}, q("tag:q26 is:recurring"))
+	assert.Equal(t, map[string]bool{milk: true, deploy: true}, q(`tag:q26 ("молоко" OR repeat:d*)`))
+	assert.Equal(t, map[string]bool{release: true, milk: true}, q("tag:q26 -tag:release OR tag:q26 title:Выпустить*"))
+	assert.Equal(t, map[string]bool{release: true}, q("tag:q26 NOT is:recurring tag:release"))
+
+	for _, bad := range []string{"tag:", "due<someday", "is:sleeping", "(tag:q26"} {
+		ret, err := postJSON("api/tasks?q="+url.QueryEscape(bad), nil, http.MethodGet)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], bad)
+	}
+
+	ret, err := postJSON("api/filters", map[string]any{"name": "q26-soon", "q": "tag:q26 due<today+5"}, http.MethodPost)
+	require.NoError(t, err)
+	require.Nil(t, ret["error"])
+	filter := ret["id"].(string)
+
+	for _, bad := range []map[string]any{
+		{"name": "", "q": "tag:q26"},
+		{"name": "q26-bad", "q": "due<someday"},
+	} {
+		ret, err = postJSON("api/filters", bad, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], bad)
+	}
+
+	assert.Equal(t, map[string]bool{release: true, milk: true}, queryIDs(t, "filter=q26-soon"))
+	// q и сохранённый фильтр должны выполняться одновременно.
+	assert.Equal(t, map[string]bool{release: true}, queryIDs(t, "filter=q26-soon&q=tag:release"))
+
+	body, err := requestJSON("api/filters", nil, http.MethodGet)
+	assert.NoError(t, err)
+	var filters map[string][]map[string]any
+	assert.NoError(t, json.Unmarshal(body, &filters))
+	names := make(map[string]bool)
+	for _, f := range filters["filters"] {
+		names[f["name"].(string)] = true
+	}
+	assert.True(t, names["q26-soon"])
+
+	ret, err = postJSON("api/filters?id="+filter, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	ret, err = postJSON("api/tasks?filter=q26-soon", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+}
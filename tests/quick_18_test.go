@@ -0,0 +1,46 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuickAdd(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	ret, err := postJSON("api/task/quick", map[string]any{
+		"text": "Позвонить маме завтра в 18:00 каждую неделю #family",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+	assert.Equal(t, "18:00", ret["at"])
+	id, _ := ret["id"].(string)
+	assert.NotEmpty(t, id)
+
+	var task Task
+	assert.NoError(t, db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id))
+	assert.Equal(t, "Позвонить маме", task.Title)
+	assert.Equal(t, time.Now().AddDate(0, 0, 1).Format(`20060102`), task.Date)
+	assert.Equal(t, "d 7", task.Repeat)
+
+	ret, err = postJSON("api/task/quick", map[string]any{
+		"text": "pay rent every month on the 1st",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+	id, _ = ret["id"].(string)
+	assert.NoError(t, db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id))
+	assert.Equal(t, "pay rent", task.Title)
+	assert.Equal(t, "m 1", task.Repeat)
+	assert.Equal(t, "01", task.Date[6:])
+
+	for _, text := range []string{"", "завтра в 18:00", "call mom at 25:00"} {
+		ret, err = postJSON("api/task/quick", map[string]any{"text": text}, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], text)
+	}
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRepeatFromCompletion(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	ret, err := postJSON("api/task", map[string]any{
+		"date":        day(5),
+		"title":       "Полить цветы",
+		"repeat":      "d 3",
+		"repeat_from": "completion",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+	id, _ := ret["id"].(string)
+
+	body, err := requestJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	var got map[string]any
+	assert.NoError(t, json.Unmarshal(body, &got))
+	assert.Equal(t, "completion", got["repeat_from"])
+
+	// PATCH без repeat_from сохраняет режим.
+	ret, err = postJSON("api/task?id="+id, map[string]any{"comment": "Фикус"}, http.MethodPatch)
+	assert.NoError(t, err)
+	assert.Equal(t, "completion", ret["repeat_from"])
+
+	// PUT в том виде, в каком его шлёт веб-интерфейс, тоже сохраняет режим,
+	// а вместе с ним теги, время и оценку.
+	ret, err = postJSON("api/task?id="+id, map[string]any{
+		"tags": []string{"дом"}, "time": "09:00", "estimate": 15,
+	}, http.MethodPatch)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+	ret, err = postJSON("api/task", map[string]any{
+		"id":      id,
+		"date":    day(5),
+		"title":   "Полить цветы",
+		"comment": "Фикус и кактус",
+		"repeat":  "d 3",
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	body, err = requestJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	got = nil
+	assert.NoError(t, json.Unmarshal(body, &got))
+	assert.Equal(t, "Фикус и кактус", got["comment"])
+	assert.Equal(t, "completion", got["repeat_from"])
+	assert.Equal(t, []any{"дом"}, got["tags"])
+	assert.Equal(t, "09:00", got["time"])
+	assert.Equal(t, 15.0, got["estimate"])
+
+	// Явно переданное пустое значение очищает поле.
+	ret, err = postJSON("api/task", map[string]any{
+		"id":          id,
+		"date":        day(5),
+		"title":       "Полить цветы",
+		"repeat":      "d 3",
+		"repeat_from": "completion",
+		"tags":        nil,
+	}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	body, err = requestJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	got = nil
+	assert.NoError(t, json.Unmarshal(body, &got))
+	assert.Nil(t, got["tags"])
+	assert.Equal(t, "09:00", got["time"])
+
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	var row Task
+	assert.NoError(t, db.Get(&row, `SELECT * FROM scheduler WHERE id=?`, id))
+	assert.Equal(t, day(3), row.Date, "Следующая дата считается от дня выполнения")
+
+	id = addTask(t, task{date: day(5), title: "Полить цветы по расписанию", repeat: "d 3"})
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	assert.NoError(t, db.Get(&row, `SELECT * FROM scheduler WHERE id=?`, id))
+	assert.Equal(t, day(8), row.Date)
+
+	ret, err = postJSON("api/task", map[string]any{
+		"title":       "Задача",
+		"repeat":      "d 3",
+		"repeat_from": "whenever",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+}
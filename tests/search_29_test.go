@@ -0,0 +1,65 @@
+//go:build sqlite_fts5
+
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type searchResult struct {
+	ID      string `json:"id"`
+	Title   string `json:"title"`
+	Snippet string `json:"snippet"`
+}
+
+func searchTasks(t *testing.T, search string) []searchResult {
+	body, err := requestJSON("api/tasks?search="+url.QueryEscape(search), nil, http.MethodGet)
+	require.NoError(t, err)
+	var m map[string][]searchResult
+	require.NoError(t, json.Unmarshal(body, &m))
+	return m["tasks"]
+}
+
+// TestFullTextSearch needs both the server and the tests built with the
+// sqlite_fts5 tag.
+func TestFullTextSearch(t *testing.T) {
+	report := addTask(t, task{date: day(1), title: "Подготовить квартальный отчёт зебрафтс", comment: "для бухгалтерии"})
+	often := addTask(t, task{date: day(1), title: "Зебрафтс зебрафтс"})
+	rarely := addTask(t, task{date: day(1), title: "Разное",
+		comment: "длинный комментарий, где среди прочих слов однажды встречается зебрафтс " +
+			"и ещё много других слов про работу, дом и планы на неделю"})
+	deploy := addTask(t, task{date: day(1), title: "Deploy the zebrafts release"})
+
+	ids := func(results []searchResult) []string {
+		var ids []string
+		for _, r := range results {
+			ids = append(ids, r.ID)
+		}
+		return ids
+	}
+
+	// Слова ищутся по префиксу, без учёта регистра и в любом порядке.
+	results := searchTasks(t, "КВАРТ подгот")
+	require.Equal(t, []string{report}, ids(results))
+	assert.Contains(t, results[0].Snippet, "<mark>Подготовить</mark>")
+	assert.Contains(t, results[0].Snippet, "<mark>квартальный</mark>")
+	assert.Equal(t, []string{report}, ids(searchTasks(t, "ОТЧЁТ зебраф")))
+	assert.Empty(t, searchTasks(t, "отчет зебраф"), "ё и е различаются")
+	assert.Equal(t, []string{report}, ids(searchTasks(t, "бухгалт зебраф")), "поиск идёт и по комментарию")
+
+	// Чем больше совпадений в коротком тексте, тем выше задача.
+	assert.Equal(t, []string{often, report, rarely}, ids(searchTasks(t, "зебрафтс")))
+
+	// Английские слова сравниваются по основе.
+	results = searchTasks(t, "deploying zebrafts")
+	require.Equal(t, []string{deploy}, ids(results))
+	assert.Contains(t, results[0].Snippet, "<mark>Deploy</mark>")
+
+	assert.Empty(t, searchTasks(t, "зебрафтсы"))
+}
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnooze(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	id := addTask(t, task{date: day(1), title: "Разовая задача"})
+	ret, err := postJSON("api/task/snooze?id="+id+"&by=3d", nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+	assert.Equal(t, day(4), ret["date"])
+	assert.Nil(t, ret["anchor"])
+
+	ret, err = postJSON("api/task/snooze?id="+id+"&until="+day(10), nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+	var row Task
+	assert.NoError(t, db.Get(&row, `SELECT * FROM scheduler WHERE id=?`, id))
+	assert.Equal(t, day(10), row.Date)
+
+	// Серия каждые 7 дней: откладываем текущее повторение на неделю и на
+	// день, а следующее после выполнения должно остаться по расписанию.
+	id = addTask(t, task{date: day(2), title: "Еженедельный отчёт", repeat: "d 7"})
+	ret, err = postJSON("api/task/snooze?id="+id+"&by=1w", nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+	assert.Equal(t, day(9), ret["date"])
+	assert.Equal(t, day(2), ret["anchor"])
+
+	ret, err = postJSON("api/task/snooze?id="+id+"&by=1d", nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Equal(t, day(10), ret["date"])
+	assert.Equal(t, day(2), ret["anchor"])
+
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	assert.NoError(t, db.Get(&row, `SELECT * FROM scheduler WHERE id=?`, id))
+	assert.Equal(t, day(9), row.Date)
+
+	for _, query := range []string{
+		"",
+		"&by=2m",
+		"&by=0d",
+		"&until=" + day(-1),
+		"&until=20241301",
+		"&by=1d&until=" + day(30),
+	} {
+		ret, err = postJSON("api/task/snooze?id="+id+query, nil, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], query)
+	}
+}
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// day returns the date n days from today.
+func day(n int) string {
+	return time.Now().AddDate(0, 0, n).Format(`20060102`)
+}
+
+func TestStatsRange(t *testing.T) {
+	db := openDB(t)
+	t.Cleanup(func() { db.Close() })
+
+	now := time.Now()
+	const taskID = "9029029"
+	t.Cleanup(func() {
+		_, err := db.Exec(`DELETE FROM completions WHERE task_id = ?`, taskID)
+		assert.NoError(t, err)
+	})
+
+	var stats struct {
+		Totals map[string]float64 `json:"totals"`
+		Done   []struct {
+			Period string `json:"period"`
+			Count  int    `json:"count"`
+		} `json:"done"`
+		Tasks []map[string]any `json:"tasks"`
+	}
+	getStats := func(query string) {
+		body, err := requestJSON("api/stats"+query, nil, http.MethodGet)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &stats))
+	}
+	getStats("")
+	before := maps.Clone(stats.Totals)
+
+	// Выполнения до диапазона, в нём и после него; последнее в диапазоне
+	// просрочено.
+	for _, c := range []struct{ date, done int }{
+		{-320, -320}, {-299, -300}, {-297, -297}, {-295, -294}, {-289, -289},
+	} {
+		_, err := db.Exec(`INSERT INTO completions (task_id, title, date, done_at) VALUES (?, 'Статистика', ?, ?)`,
+			taskID, day(c.date), now.AddDate(0, 0, c.done).Format(time.RFC3339))
+		require.NoError(t, err)
+	}
+	for _, task := range []struct {
+		date   int
+		repeat string
+	}{{-296, ""}, {-293, "d 1"}, {-320, ""}} {
+		res, err := db.Exec(`INSERT INTO scheduler (date, title, comment, repeat) VALUES (?, 'Статистика', '', ?)`,
+			day(task.date), task.repeat)
+		require.NoError(t, err)
+		id, err := res.LastInsertId()
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_, err := db.Exec(`DELETE FROM scheduler WHERE id = ?`, id)
+			assert.NoError(t, err)
+		})
+	}
+
+	// Открытые и просроченные задачи считаются на сегодня, а не в диапазоне.
+	getStats("")
+	assert.Equal(t, before["open"]+3, stats.Totals["open"])
+	assert.Equal(t, before["overdue"]+2, stats.Totals["overdue"])
+
+	getStats("?from=" + day(-300) + "&to=" + day(-290))
+	delete(stats.Totals, "open")
+	delete(stats.Totals, "overdue")
+	assert.Equal(t, map[string]float64{
+		"completed": 3, "on_time": 2, "late": 1, "on_time_rate": 2.0 / 3,
+	}, stats.Totals)
+	assert.Len(t, stats.Done, 11)
+	done := 0
+	for _, d := range stats.Done {
+		done += d.Count
+	}
+	assert.Equal(t, 3, done)
+	require.Len(t, stats.Tasks, 1)
+	assert.Equal(t, taskID, stats.Tasks[0]["task_id"])
+	assert.EqualValues(t, 3, stats.Tasks[0]["completed"])
+	// Текущая серия — на сегодня, с учётом выполнения после диапазона.
+	assert.EqualValues(t, 1, stats.Tasks[0]["current_streak"])
+	assert.EqualValues(t, 2, stats.Tasks[0]["best_streak"])
+
+	ret, err := postJSON("api/stats?from="+day(-400)+"&to="+day(-34), nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+	ret, err = postJSON("api/stats?from="+day(-400)+"&to="+day(-35), nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+}
@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplates(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	ret, err := postJSON("api/templates", map[string]any{
+		"name":      "Релиз",
+		"title":     "Релиз {{sprint}}",
+		"comment":   "Выпуск от {{date}}",
+		"repeat":    "",
+		"tags":      []string{"#Release"},
+		"checklist": []string{"Заморозить ветку {{sprint}}", "Выложить"},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+	tplID, _ := ret["id"].(string)
+	assert.NotEmpty(t, tplID)
+
+	body, err := requestJSON("api/templates", nil, http.MethodGet)
+	assert.NoError(t, err)
+	var list map[string][]map[string]any
+	assert.NoError(t, json.Unmarshal(body, &list))
+	found := false
+	for _, v := range list["templates"] {
+		if v["id"] == tplID {
+			found = true
+			assert.Equal(t, []any{"release"}, v["tags"])
+		}
+	}
+	assert.True(t, found)
+
+	ret, err = postJSON("api/templates/instantiate?id="+tplID, map[string]any{}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"], "Без переменной sprint шаблон не заполнить")
+
+	date := time.Now().AddDate(0, 0, 3).Format(`20060102`)
+	ret, err = postJSON("api/templates/instantiate?id="+tplID, map[string]any{
+		"date": date,
+		"vars": map[string]string{"sprint": "42"},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+	id, _ := ret["id"].(string)
+
+	var task Task
+	assert.NoError(t, db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id))
+	assert.Equal(t, "Релиз 42", task.Title)
+	assert.Equal(t, "Выпуск от "+date, task.Comment)
+	assert.Equal(t, date, task.Date)
+
+	items := getChecklist(t, id)
+	assert.Len(t, items, 2)
+	assert.Equal(t, "Заморозить ветку 42", items[0].Title)
+	assert.Equal(t, "Выложить", items[1].Title)
+
+	// {{date}} is filled in with the date the task gets, not the past one asked for.
+	today := time.Now().Format(`20060102`)
+	ret, err = postJSON("api/templates/instantiate?id="+tplID, map[string]any{
+		"date": time.Now().AddDate(0, 0, -3).Format(`20060102`),
+		"vars": map[string]string{"sprint": "43"},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+	id, _ = ret["id"].(string)
+	assert.NoError(t, db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id))
+	assert.Equal(t, "Выпуск от "+today, task.Comment)
+	assert.Equal(t, today, task.Date)
+
+	ret, err = postJSON("api/templates", map[string]any{
+		"name":      "Пункт из переменной",
+		"title":     "Шаги",
+		"checklist": []string{"{{step}}"},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+	stepID, _ := ret["id"].(string)
+	ret, err = postJSON("api/templates/instantiate?id="+stepID, map[string]any{
+		"vars": map[string]string{"step": " "},
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"], "Пустой пункт чек-листа")
+	ret, err = postJSON("api/templates?id="+stepID, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+
+	for _, tpl := range []map[string]any{
+		{"title": "Без имени"},
+		{"name": "Пустой"},
+		{"name": "Плохой повтор", "title": "Задача", "repeat": "ooops"},
+	} {
+		ret, err = postJSON("api/templates", tpl, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], tpl)
+	}
+
+	ret, err = postJSON("api/templates?id="+tplID, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	ret, err = postJSON("api/templates/instantiate?id="+tplID, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+}
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func trashIDs(t *testing.T) map[string]bool {
+	body, err := requestJSON("api/trash", nil, http.MethodGet)
+	assert.NoError(t, err)
+
+	var m map[string][]map[string]any
+	assert.NoError(t, json.Unmarshal(body, &m))
+	ids := make(map[string]bool)
+	for _, v := range m["tasks"] {
+		ids[v["id"].(string)] = true
+		assert.NotEmpty(t, v["deleted_at"])
+	}
+	return ids
+}
+
+func TestTrash(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	date := time.Now().AddDate(0, 0, 1).Format(`20060102`)
+	id := addTask(t, task{date: date, title: "Ежегодный отчёт", repeat: "y"})
+
+	ret, err := postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	notFoundTask(t, id)
+	assert.True(t, trashIDs(t)[id])
+
+	ret, err = postJSON("api/trash/restore?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+	assert.False(t, trashIDs(t)[id])
+
+	var task Task
+	assert.NoError(t, db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id))
+	assert.Equal(t, "Ежегодный отчёт", task.Title)
+	assert.Equal(t, "y", task.Repeat)
+
+	ret, err = postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	ret, err = postJSON("api/trash?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+	assert.False(t, trashIDs(t)[id])
+
+	ret, err = postJSON("api/trash/restore?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+}
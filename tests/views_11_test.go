@@ -0,0 +1,78 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// viewsLimit is above the number of tasks the tests create, so that a view
+// is never cut short by paging.
+const viewsLimit = 500
+
+func viewIDs(t *testing.T, view string) map[string]bool {
+	body, err := requestJSON("api/tasks?limit="+strconv.Itoa(viewsLimit)+"&view="+view, nil, http.MethodGet)
+	assert.NoError(t, err)
+
+	var m map[string][]map[string]any
+	assert.NoError(t, json.Unmarshal(body, &m))
+	ids := make(map[string]bool)
+	for _, v := range m["tasks"] {
+		ids[v["id"].(string)] = true
+	}
+	return ids
+}
+
+// serverNow returns the server clock: the day in TODO_NOW when the server is
+// pinned to it, as the views are tested against, or the real time otherwise.
+func serverNow(t *testing.T) time.Time {
+	date := os.Getenv("TODO_NOW")
+	if date == "" {
+		return time.Now()
+	}
+	day, err := time.ParseInLocation(`20060102`, date, time.Local)
+	require.NoError(t, err)
+	return day.Add(12 * time.Hour)
+}
+
+func TestViews(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := serverNow(t)
+	today := addTask(t, task{date: now.Format(`20060102`), title: "Сегодня"})
+	soon := addTask(t, task{date: now.AddDate(0, 0, 3).Format(`20060102`), title: "Скоро"})
+	later := addTask(t, task{date: now.AddDate(0, 0, 20).Format(`20060102`), title: "Потом"})
+
+	res, err := db.Exec(`INSERT INTO scheduler (date, title, comment, repeat) VALUES (?, 'Просрочено', '', '')`,
+		now.AddDate(0, 0, -2).Format(`20060102`))
+	assert.NoError(t, err)
+	overdueID, err := res.LastInsertId()
+	assert.NoError(t, err)
+	overdue := strconv.FormatInt(overdueID, 10)
+
+	ids := viewIDs(t, "today")
+	assert.True(t, ids[today])
+	assert.False(t, ids[soon] || ids[later] || ids[overdue])
+
+	ids = viewIDs(t, "upcoming")
+	assert.True(t, ids[soon])
+	assert.False(t, ids[today] || ids[later] || ids[overdue])
+
+	ids = viewIDs(t, "upcoming&days=30")
+	assert.True(t, ids[soon] && ids[later])
+
+	ids = viewIDs(t, "overdue")
+	assert.True(t, ids[overdue])
+	assert.False(t, ids[today] || ids[soon] || ids[later])
+
+	ret, err := postJSON("api/tasks?view=yesterday", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+}
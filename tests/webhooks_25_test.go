@@ -0,0 +1,113 @@
+package tests
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type delivery struct {
+	event     string
+	signature string
+	body      []byte
+}
+
+func TestWebhooks(t *testing.T) {
+	received := make(chan delivery, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- delivery{r.Header.Get("X-Todo-Event"), r.Header.Get("X-Todo-Signature"), body}
+	}))
+	defer srv.Close()
+
+	for _, wh := range []map[string]any{
+		{"url": "ftp://example.com"},
+		{"url": srv.URL, "events": []string{"task.renamed"}},
+	} {
+		ret, err := postJSON("api/webhooks", wh, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], wh)
+	}
+
+	ret, err := postJSON("api/webhooks", map[string]any{
+		"url":    srv.URL,
+		"secret": "s3cret",
+		"events": []string{"task.created", "task.deleted"},
+	}, http.MethodPost)
+	require.NoError(t, err)
+	require.Nil(t, ret["error"])
+	webhook, _ := ret["id"].(string)
+	assert.Equal(t, "s3cret", ret["secret"])
+
+	body, err := requestJSON("api/webhooks", nil, http.MethodGet)
+	assert.NoError(t, err)
+	var list map[string][]map[string]any
+	assert.NoError(t, json.Unmarshal(body, &list))
+	for _, wh := range list["webhooks"] {
+		assert.Empty(t, wh["secret"], "Секрет показывается только при создании")
+	}
+
+	// task.updated не подписан и не доставляется.
+	id := addTask(t, task{date: time.Now().AddDate(0, 0, 1).Format(`20060102`), title: "Вебхук"})
+	ret, err = postJSON("api/task?id="+id, map[string]any{"title": "Вебхук изменён"}, http.MethodPatch)
+	assert.NoError(t, err)
+	assert.Nil(t, ret["error"])
+	ret, err = postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+
+	// Доставки уходят параллельно, поэтому порядок не гарантирован.
+	got := make(map[string]delivery)
+	for range 2 {
+		select {
+		case d := <-received:
+			got[d.event] = d
+		case <-time.After(5 * time.Second):
+			t.Fatalf("got %d deliveries, want 2", len(got))
+		}
+	}
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	for _, want := range []string{"task.created", "task.deleted"} {
+		d, ok := got[want]
+		if !assert.True(t, ok, "no %s delivery", want) {
+			continue
+		}
+		mac.Reset()
+		mac.Write(d.body)
+		assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), d.signature)
+		var event map[string]any
+		assert.NoError(t, json.Unmarshal(d.body, &event))
+		assert.Equal(t, want, event["type"])
+		assert.Equal(t, id, event["task"].(map[string]any)["id"])
+	}
+
+	var deliveries map[string][]map[string]any
+	assert.Eventually(t, func() bool {
+		body, err = requestJSON("api/webhooks/deliveries?webhook_id="+webhook, nil, http.MethodGet)
+		if err != nil || json.Unmarshal(body, &deliveries) != nil || len(deliveries["deliveries"]) != 2 {
+			return false
+		}
+		for _, d := range deliveries["deliveries"] {
+			if d["status"] != "delivered" {
+				return false
+			}
+		}
+		return true
+	}, 5*time.Second, 50*time.Millisecond)
+
+	ret, err = postJSON("api/webhooks?id="+webhook, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	ret, err = postJSON("api/webhooks?id="+webhook, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+}